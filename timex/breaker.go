@@ -0,0 +1,385 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultBreakerBuckets     = 10
+	defaultBreakerInterval    = time.Second
+	defaultBreakerRatio       = 0.5
+	defaultBreakerMinRequests = 10
+	defaultBreakerCoolDown    = 5 * time.Second
+	defaultBreakerProbes      = 1
+
+	breakerEventName = "breaker.state_change"
+)
+
+const (
+	// StateClosed lets the requests go through.
+	StateClosed State = iota
+	// StateOpen rejects the requests until the cool-down elapses.
+	StateOpen
+	// StateHalfOpen lets a few probes go through to decide whether to close.
+	StateHalfOpen
+)
+
+var (
+	// ErrBreakerOpen is returned when the breaker is open, or throttles the request.
+	ErrBreakerOpen = errors.New("circuit breaker is open")
+	// ErrTooManyProbes is returned when the breaker is half-open and the probes are in flight.
+	ErrTooManyProbes = errors.New("circuit breaker has too many probes")
+)
+
+type (
+	// State is the state of a Breaker.
+	State int
+
+	// BreakerOption customizes a Breaker.
+	BreakerOption func(*breakerOptions)
+
+	breakerOptions struct {
+		clock               Clock
+		buckets             int
+		interval            time.Duration
+		ratio               float64
+		minRequests         int
+		consecutiveFailures int
+		coolDown            time.Duration
+		probes              int
+		adaptive            bool
+		k                   float64
+		acceptable          func(err error) bool
+		onStateChange       func(name string, from, to State)
+	}
+
+	// A Breaker is a circuit breaker. It trips open on the ratio of failures
+	// over a rolling window, or on consecutive failures, rejects the requests
+	// during a cool-down, then lets probes go through while half-open, and
+	// closes once they all succeed.
+	//
+	// In the adaptive mode, the breaker implements the client-side throttling
+	// of the Google SRE book instead: it stays closed and rejects the requests
+	// with a probability growing with the failures of the window.
+	Breaker struct {
+		name string
+		opts breakerOptions
+
+		mu          sync.Mutex
+		state       State
+		generation  uint64
+		window      *RollingWindow
+		consecutive int
+		openedAt    time.Time
+		probes      int
+		successes   int
+	}
+)
+
+// WithBreakerClock sets the Clock timing the breaker.
+func WithBreakerClock(clock Clock) BreakerOption {
+	return func(o *breakerOptions) {
+		o.clock = clock
+	}
+}
+
+// WithBreakerWindow sets the rolling window of the failure ratio to buckets
+// of interval, defaults to 10 buckets of 1s.
+func WithBreakerWindow(buckets int, interval time.Duration) BreakerOption {
+	return func(o *breakerOptions) {
+		o.buckets = buckets
+		o.interval = interval
+	}
+}
+
+// WithFailureRatio trips the breaker once the ratio of failures of the window
+// reaches ratio, with at least minRequests in the window, defaults to 0.5 of
+// 10 requests. Zero ratio disables it.
+func WithFailureRatio(ratio float64, minRequests int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.ratio = ratio
+		o.minRequests = minRequests
+	}
+}
+
+// WithConsecutiveFailures trips the breaker after n consecutive failures,
+// zero disables it, which is the default.
+func WithConsecutiveFailures(n int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.consecutiveFailures = n
+	}
+}
+
+// WithCoolDown sets how long the breaker stays open, defaults to 5s.
+func WithCoolDown(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) {
+		o.coolDown = d
+	}
+}
+
+// WithProbes sets the number of probes of the half-open breaker, which all
+// have to succeed to close it, defaults to 1.
+func WithProbes(n int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.probes = n
+	}
+}
+
+// WithAdaptiveThrottling switches the breaker to the adaptive mode, rejecting
+// the requests with the probability max(0, (requests-k*accepts)/(requests+1))
+// over the window. The lower k, the more aggressive, 2 is a good start.
+func WithAdaptiveThrottling(k float64) BreakerOption {
+	return func(o *breakerOptions) {
+		o.adaptive = true
+		o.k = k
+	}
+}
+
+// WithAcceptable sets the function reporting whether an error is not a
+// failure of the protected service, like a not found error.
+func WithAcceptable(acceptable func(err error) bool) BreakerOption {
+	return func(o *breakerOptions) {
+		o.acceptable = acceptable
+	}
+}
+
+// WithStateChange sets the function called on the state transitions.
+func WithStateChange(fn func(name string, from, to State)) BreakerOption {
+	return func(o *breakerOptions) {
+		o.onStateChange = fn
+	}
+}
+
+// NewBreaker returns a closed Breaker.
+func NewBreaker(name string, opts ...BreakerOption) *Breaker {
+	o := breakerOptions{
+		clock:       NewRealClock(),
+		buckets:     defaultBreakerBuckets,
+		interval:    defaultBreakerInterval,
+		ratio:       defaultBreakerRatio,
+		minRequests: defaultBreakerMinRequests,
+		coolDown:    defaultBreakerCoolDown,
+		probes:      defaultBreakerProbes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.probes <= 0 {
+		o.probes = defaultBreakerProbes
+	}
+
+	return &Breaker{
+		name:   name,
+		opts:   o,
+		window: NewRollingWindowWithClock(o.clock, o.buckets, o.interval),
+	}
+}
+
+// Name returns the name of the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	state := b.state
+	changed := b.coolDownLocked()
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(context.Background(), state, StateHalfOpen)
+		return StateHalfOpen
+	}
+	return state
+}
+
+// Allow checks whether a request may go through. If so, the caller must call
+// done with the result of the request, otherwise it returns ErrBreakerOpen
+// or ErrTooManyProbes.
+//
+// The state transitions are added as events to the span of ctx.
+func (b *Breaker) Allow(ctx context.Context) (done func(err error), err error) {
+	b.mu.Lock()
+	from := b.state
+	changed := b.coolDownLocked()
+	err = b.allowLocked()
+	generation := b.generation
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(ctx, from, StateHalfOpen)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) {
+		b.done(ctx, generation, err)
+	}, nil
+}
+
+// Do calls fn if the breaker allows it, and records its result. A panic of
+// fn is recorded as a failure.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	done, err := b.Allow(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			done(fmt.Errorf("panic: %v", p))
+			panic(p)
+		}
+	}()
+
+	err = fn(ctx)
+	done(err)
+	return err
+}
+
+// coolDownLocked moves the open breaker to half-open once the cool-down
+// elapsed, it reports whether the state changed.
+func (b *Breaker) coolDownLocked() bool {
+	if b.state != StateOpen || b.opts.clock.Since(b.openedAt) < b.opts.coolDown {
+		return false
+	}
+
+	b.setStateLocked(StateHalfOpen)
+	return true
+}
+
+func (b *Breaker) allowLocked() error {
+	switch b.state {
+	case StateOpen:
+		return ErrBreakerOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.probes {
+			return ErrTooManyProbes
+		}
+		b.probes++
+	default:
+		if b.opts.adaptive && b.throttleLocked() {
+			// the rejected requests count as not accepted.
+			b.window.Add(1)
+			return ErrBreakerOpen
+		}
+	}
+
+	return nil
+}
+
+// throttleLocked reports whether to reject a request in the adaptive mode.
+func (b *Breaker) throttleLocked() bool {
+	stats := b.window.Stats()
+	requests := float64(stats.Count)
+	accepts := requests - stats.Sum
+	p := math.Max(0, (requests-b.opts.k*accepts)/(requests+1))
+
+	return p > 0 && rand.Float64() < p
+}
+
+func (b *Breaker) done(ctx context.Context, generation uint64, err error) {
+	failure := err != nil && (b.opts.acceptable == nil || !b.opts.acceptable(err))
+
+	b.mu.Lock()
+	// the result of a request allowed before a transition is outdated.
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+
+	from := b.state
+	to := from
+	switch from {
+	case StateHalfOpen:
+		if failure {
+			to = StateOpen
+		} else if b.successes++; b.successes >= b.opts.probes {
+			to = StateClosed
+		}
+	case StateClosed:
+		if failure {
+			b.window.Add(1)
+			b.consecutive++
+		} else {
+			b.window.Add(0)
+			b.consecutive = 0
+		}
+		if failure && !b.opts.adaptive && b.tripLocked() {
+			to = StateOpen
+		}
+	}
+	if to != from {
+		b.setStateLocked(to)
+	}
+	b.mu.Unlock()
+
+	if to != from {
+		b.notify(ctx, from, to)
+	}
+}
+
+// tripLocked reports whether the failures trip the closed breaker.
+func (b *Breaker) tripLocked() bool {
+	if n := b.opts.consecutiveFailures; n > 0 && b.consecutive >= n {
+		return true
+	}
+
+	if b.opts.ratio <= 0 {
+		return false
+	}
+	stats := b.window.Stats()
+	return stats.Count >= int64(b.opts.minRequests) && stats.Sum/float64(stats.Count) >= b.opts.ratio
+}
+
+func (b *Breaker) setStateLocked(state State) {
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.probes = 0
+	b.successes = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = b.opts.clock.Now()
+	case StateClosed:
+		b.window = NewRollingWindowWithClock(b.opts.clock, b.opts.buckets, b.opts.interval)
+	}
+}
+
+func (b *Breaker) notify(ctx context.Context, from, to State) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.AddEvent(breakerEventName, trace.WithAttributes(
+			attribute.String("breaker.name", b.name),
+			attribute.String("breaker.from", from.String()),
+			attribute.String("breaker.to", to.String()),
+		))
+	}
+
+	if b.opts.onStateChange != nil {
+		b.opts.onStateChange(b.name, from, to)
+	}
+}
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
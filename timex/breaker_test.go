@@ -0,0 +1,198 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errBreaker = errors.New("breaker")
+
+func failN(b *Breaker, n int) {
+	for i := 0; i < n; i++ {
+		_ = b.Do(context.Background(), func(ctx context.Context) error {
+			return errBreaker
+		})
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	var transitions []string
+	b := NewBreaker("test", WithBreakerClock(clock), WithFailureRatio(0.5, 4),
+		WithCoolDown(time.Second), WithStateChange(func(name string, from, to State) {
+			transitions = append(transitions, fmt.Sprintf("%s: %s -> %s", name, from, to))
+		}))
+	assert.Equal(t, "test", b.Name())
+
+	// below the minimum of requests.
+	failN(b, 3)
+	assert.Equal(t, StateClosed, b.State())
+	failN(b, 1)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, ErrBreakerOpen, b.Do(context.Background(), func(ctx context.Context) error {
+		t.Fatal("called while open")
+		return nil
+	}))
+
+	clock.Advance(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.Nil(t, b.Do(context.Background(), func(ctx context.Context) error {
+		return nil
+	}))
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, []string{
+		"test: closed -> open",
+		"test: open -> half-open",
+		"test: half-open -> closed",
+	}, transitions)
+}
+
+func TestBreakerRatioWindow(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	b := NewBreaker("test", WithBreakerClock(clock), WithFailureRatio(0.5, 4),
+		WithBreakerWindow(2, time.Second))
+
+	failN(b, 3)
+	// the failures leave the window.
+	clock.Advance(2 * time.Second)
+	failN(b, 1)
+	assert.Equal(t, StateClosed, b.State())
+
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, b.Do(context.Background(), func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	failN(b, 2)
+	assert.Equal(t, StateClosed, b.State())
+	failN(b, 1)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	b := NewBreaker("test", WithBreakerClock(clock), WithFailureRatio(0, 0),
+		WithConsecutiveFailures(3), WithCoolDown(time.Second), WithProbes(2),
+		WithAcceptable(func(err error) bool {
+			return errors.Is(err, context.Canceled)
+		}))
+
+	failN(b, 2)
+	_ = b.Do(context.Background(), func(ctx context.Context) error {
+		return context.Canceled
+	})
+	failN(b, 2)
+	assert.Equal(t, StateClosed, b.State())
+	failN(b, 1)
+	assert.Equal(t, StateOpen, b.State())
+
+	// two probes at most, which have to succeed.
+	clock.Advance(time.Second)
+	done1, err := b.Allow(context.Background())
+	assert.Nil(t, err)
+	done2, err := b.Allow(context.Background())
+	assert.Nil(t, err)
+	_, err = b.Allow(context.Background())
+	assert.Equal(t, ErrTooManyProbes, err)
+	done1(nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+	done2(errBreaker)
+	assert.Equal(t, StateOpen, b.State())
+
+	clock.Advance(time.Second)
+	for i := 0; i < 2; i++ {
+		done, err := b.Allow(context.Background())
+		assert.Nil(t, err)
+		done(nil)
+	}
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreakerOutdatedResult(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	b := NewBreaker("test", WithBreakerClock(clock), WithConsecutiveFailures(1),
+		WithCoolDown(time.Second))
+
+	done, err := b.Allow(context.Background())
+	assert.Nil(t, err)
+	failN(b, 1)
+	assert.Equal(t, StateOpen, b.State())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	// allowed while closed, it does not count as a probe.
+	done(nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+}
+
+func TestBreakerPanic(t *testing.T) {
+	b := NewBreaker("test", WithConsecutiveFailures(1))
+	assert.Panics(t, func() {
+		_ = b.Do(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerAdaptive(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	b := NewBreaker("test", WithBreakerClock(clock), WithAdaptiveThrottling(2))
+
+	// no rejection while the requests succeed.
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, b.Do(context.Background(), func(ctx context.Context) error {
+			return nil
+		}))
+	}
+
+	clock.Advance(time.Minute)
+	var rejected int
+	for i := 0; i < 1000; i++ {
+		err := b.Do(context.Background(), func(ctx context.Context) error {
+			return errBreaker
+		})
+		if errors.Is(err, ErrBreakerOpen) {
+			rejected++
+		}
+	}
+	assert.True(t, rejected > 900, rejected)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreakerSpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(sdk.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "breaker")
+
+	b := NewBreaker("test", WithConsecutiveFailures(1))
+	_ = b.Do(ctx, func(ctx context.Context) error {
+		return errBreaker
+	})
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, breakerEventName, events[0].Name)
+	attrs := make(map[string]interface{})
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"breaker.name": "test",
+		"breaker.from": "closed",
+		"breaker.to":   "open",
+	}, attrs)
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "State(9)", State(9).String())
+}
@@ -0,0 +1,289 @@
+package timex
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock interface wraps the time functions, so that the time-dependent
+	// code can be tested with a FakeClock.
+	Clock interface {
+		Now() time.Time
+		Since(t time.Time) time.Duration
+		After(d time.Duration) <-chan time.Time
+		Sleep(d time.Duration)
+		NewTimer(d time.Duration) Timer
+		NewTicker(d time.Duration) Ticker
+	}
+
+	// Timer interface wraps the Chan, Stop and Reset methods of time.Timer.
+	Timer interface {
+		Chan() <-chan time.Time
+		Stop() bool
+		Reset(d time.Duration) bool
+	}
+
+	// FakeClock interface is a Clock whose time only moves on Advance or Set,
+	// it is used for unit testing.
+	FakeClock interface {
+		Clock
+		// Advance moves the clock forward by d, and fires the timers and tickers
+		// due meanwhile in order.
+		Advance(d time.Duration)
+		// Set moves the clock to t, which must not be before the current time.
+		Set(t time.Time)
+		// BlockUntil blocks until n timers, tickers or sleepers are waiting.
+		BlockUntil(n int)
+	}
+
+	realClock struct{}
+
+	realTimer struct {
+		*time.Timer
+	}
+
+	fakeClock struct {
+		mu      sync.Mutex
+		cond    *sync.Cond
+		now     time.Time
+		seq     uint64
+		waiters []*fakeWaiter
+	}
+
+	// fakeWaiter is a timer of the fakeClock, or a ticker if period is positive.
+	fakeWaiter struct {
+		clock    *fakeClock
+		c        chan time.Time
+		deadline time.Time
+		period   time.Duration
+		seq      uint64
+	}
+
+	fakeClockTicker struct {
+		*fakeWaiter
+	}
+)
+
+// NewRealClock returns a Clock reading the system time.
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{
+		Timer: time.NewTimer(d),
+	}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return NewTicker(d)
+}
+
+func (rt *realTimer) Chan() <-chan time.Time {
+	return rt.C
+}
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) FakeClock {
+	fc := &fakeClock{now: now}
+	fc.cond = sync.NewCond(&fc.mu)
+	return fc
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *fakeClock) Since(t time.Time) time.Duration {
+	return fc.Now().Sub(t)
+}
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	return fc.NewTimer(d).Chan()
+}
+
+func (fc *fakeClock) Sleep(d time.Duration) {
+	<-fc.After(d)
+}
+
+func (fc *fakeClock) NewTimer(d time.Duration) Timer {
+	return fc.newWaiter(d, 0)
+}
+
+func (fc *fakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	return fakeClockTicker{fc.newWaiter(d, d)}
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.Set(fc.Now().Add(d))
+}
+
+func (fc *fakeClock) Set(t time.Time) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for {
+		w := fc.nextDue(t)
+		if w == nil {
+			break
+		}
+
+		fc.now = w.deadline
+		w.fire(fc.now)
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			fc.removeLocked(w)
+		}
+	}
+	if t.After(fc.now) {
+		fc.now = t
+	}
+}
+
+func (fc *fakeClock) BlockUntil(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for len(fc.waiters) < n {
+		fc.cond.Wait()
+	}
+}
+
+func (fc *fakeClock) newWaiter(d, period time.Duration) *fakeWaiter {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	w := &fakeWaiter{
+		clock:  fc,
+		c:      make(chan time.Time, 1),
+		period: period,
+	}
+	fc.addLocked(w, d)
+	if d <= 0 {
+		// fires immediately, like time.NewTimer.
+		w.fire(fc.now)
+		fc.removeLocked(w)
+	}
+
+	return w
+}
+
+// nextDue returns the earliest waiter due at t, the waiters created first
+// win the ties so that the firing order is deterministic.
+func (fc *fakeClock) nextDue(t time.Time) *fakeWaiter {
+	if len(fc.waiters) == 0 {
+		return nil
+	}
+
+	sort.Slice(fc.waiters, func(i, j int) bool {
+		a, b := fc.waiters[i], fc.waiters[j]
+		if a.deadline.Equal(b.deadline) {
+			return a.seq < b.seq
+		}
+		return a.deadline.Before(b.deadline)
+	})
+	if w := fc.waiters[0]; !w.deadline.After(t) {
+		return w
+	}
+
+	return nil
+}
+
+func (fc *fakeClock) addLocked(w *fakeWaiter, d time.Duration) {
+	fc.seq++
+	w.seq = fc.seq
+	w.deadline = fc.now.Add(d)
+	fc.waiters = append(fc.waiters, w)
+	fc.cond.Broadcast()
+}
+
+func (fc *fakeClock) removeLocked(w *fakeWaiter) bool {
+	for i, waiter := range fc.waiters {
+		if waiter == w {
+			fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+			fc.cond.Broadcast()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *fakeWaiter) Chan() <-chan time.Time {
+	return w.c
+}
+
+// Stop stops the timer or the ticker, it reports whether it was active.
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.removeLocked(w)
+}
+
+// Reset changes the timer or the ticker to expire after d, it reports
+// whether it was active.
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+
+	active := w.clock.removeLocked(w)
+	w.clock.addLocked(w, d)
+	if d <= 0 {
+		w.fire(w.clock.now)
+		w.clock.removeLocked(w)
+	}
+
+	return active
+}
+
+// Reset stops the ticker and resets its period to d.
+func (t fakeClockTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.removeLocked(t.fakeWaiter)
+	t.period = d
+	t.clock.addLocked(t.fakeWaiter, d)
+}
+
+// Stop stops the ticker.
+func (t fakeClockTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+// fire sends now without blocking, the ticks are dropped if the receiver
+// is slow, like time.Ticker.
+func (w *fakeWaiter) fire(now time.Time) {
+	select {
+	case w.c <- now:
+	default:
+	}
+}
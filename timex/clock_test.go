@@ -0,0 +1,140 @@
+package timex
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestRealClock(t *testing.T) {
+	clock := NewRealClock()
+	start := clock.Now()
+	clock.Sleep(time.Millisecond)
+	assert.True(t, clock.Since(start) >= time.Millisecond)
+
+	<-clock.After(time.Millisecond)
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.Chan()
+	assert.False(t, timer.Stop())
+
+	ticker := NewTickerWithClock(clock, time.Millisecond)
+	<-ticker.Chan()
+	ticker.Stop()
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, fakeEpoch.Add(time.Second), <-timer.Chan())
+	assert.False(t, timer.Stop())
+
+	assert.False(t, timer.Reset(time.Minute))
+	assert.True(t, timer.Stop())
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.Chan():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+	assert.Equal(t, fakeEpoch.Add(time.Hour+time.Second), clock.Now())
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	ticker := NewTickerWithClock(clock, time.Second)
+
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		ticks = append(ticks, <-ticker.Chan())
+	}
+	assert.Equal(t, []time.Time{
+		fakeEpoch.Add(time.Second),
+		fakeEpoch.Add(2 * time.Second),
+		fakeEpoch.Add(3 * time.Second),
+	}, ticks)
+
+	// the ticks are dropped when the receiver is slow.
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, fakeEpoch.Add(4*time.Second), <-ticker.Chan())
+
+	ticker.Stop()
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+
+	assert.Panics(t, func() {
+		clock.NewTicker(0)
+	})
+}
+
+func TestFakeClockOrder(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	var fired []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, tt := range []struct {
+		name  string
+		delay time.Duration
+	}{
+		{"c", 3 * time.Second},
+		{"a", time.Second},
+		{"b", 2 * time.Second},
+	} {
+		timer := clock.NewTimer(tt.delay)
+		name := tt.name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			now := <-timer.Chan()
+			mu.Lock()
+			fired = append(fired, name+now.Sub(fakeEpoch).String())
+			mu.Unlock()
+		}()
+	}
+
+	clock.Advance(time.Minute)
+	wg.Wait()
+	assert.ElementsMatch(t, []string{"a1s", "b2s", "c3s"}, fired)
+}
+
+func TestFakeClockSleep(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	done := make(chan time.Time)
+	go func() {
+		clock.Sleep(time.Minute)
+		done <- clock.Now()
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	assert.Equal(t, fakeEpoch.Add(time.Minute), <-done)
+
+	// a non-positive delay fires immediately.
+	<-clock.After(0)
+}
+
+func TestElapsedTimerWithClock(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	timer := NewElapsedTimerWithClock(clock)
+	clock.Advance(1500 * time.Millisecond)
+	assert.Equal(t, 1500*time.Millisecond, timer.Duration())
+	assert.Equal(t, "1.5s", timer.Elapsed())
+	assert.Equal(t, "1500.0ms", timer.ElapsedMs())
+}
@@ -0,0 +1,110 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoarseClock(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	c := NewCoarseClockWithClock(clock, time.Millisecond)
+
+	// reads the clock while not started.
+	assertTime(t, fakeEpoch, c.Time())
+	clock.Advance(time.Microsecond)
+	assertTime(t, fakeEpoch.Add(time.Microsecond), c.Time())
+
+	c.Start()
+	c.Start()
+	start := c.Now()
+	clock.Advance(500 * time.Microsecond)
+	// the cached time is behind.
+	assert.Equal(t, start, c.Now())
+	assertTime(t, fakeEpoch.Add(time.Microsecond), c.Time())
+
+	clock.Advance(500 * time.Microsecond)
+	assert.Eventually(t, func() bool {
+		return c.Since(start) == time.Millisecond
+	}, time.Second, time.Millisecond)
+	assertTime(t, fakeEpoch.Add(1001*time.Microsecond), c.Time())
+
+	c.Stop()
+	c.Stop()
+	clock.Advance(time.Microsecond)
+	assertTime(t, fakeEpoch.Add(1002*time.Microsecond), c.Time())
+
+	// restarts.
+	c.Start()
+	clock.Advance(time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return c.Time().Equal(fakeEpoch.Add(2002 * time.Microsecond))
+	}, time.Second, time.Millisecond)
+	c.Stop()
+}
+
+func assertTime(t *testing.T, expected, actual time.Time) {
+	t.Helper()
+	assert.True(t, expected.Equal(actual), actual)
+}
+
+func TestCoarseClockReal(t *testing.T) {
+	c := NewCoarseClock(time.Millisecond)
+	c.Start()
+	defer c.Stop()
+
+	diff := c.Time().Sub(time.Now())
+	assert.True(t, diff <= 0 && diff > -time.Second, diff)
+	assert.True(t, Since(c.Now()) >= 0)
+}
+
+func TestCoarseClockPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		NewCoarseClock(0)
+	})
+}
+
+func BenchmarkTimeNow(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = time.Now()
+	}
+}
+
+func BenchmarkNow(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = Now()
+	}
+}
+
+func BenchmarkCoarseClockNow(b *testing.B) {
+	c := NewCoarseClock(time.Millisecond)
+	c.Start()
+	defer c.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Now()
+	}
+}
+
+func BenchmarkNowParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = Now()
+		}
+	})
+}
+
+func BenchmarkCoarseClockNowParallel(b *testing.B) {
+	c := NewCoarseClock(time.Millisecond)
+	c.Start()
+	defer c.Stop()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = c.Now()
+		}
+	})
+}
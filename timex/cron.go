@@ -0,0 +1,346 @@
+package timex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds the search of the next activation of a schedule,
+// like Feb 30th which never happens.
+const cronSearchYears = 5
+
+type (
+	// A Schedule returns the next activation time, after t.
+	Schedule interface {
+		// Next returns the next activation time after t, or the zero time if
+		// there is none.
+		Next(t time.Time) time.Time
+	}
+
+	// cronSchedule is a cron expression, each field is a bit set of the
+	// matching values.
+	cronSchedule struct {
+		second, minute, hour, dom, month, dow uint64
+		// domStar or dowStar means that the day has to match both fields,
+		// otherwise any of them, like the standard cron.
+		domStar, dowStar bool
+		location         *time.Location
+	}
+
+	// everySchedule runs at a fixed interval.
+	everySchedule struct {
+		interval time.Duration
+	}
+
+	cronBounds struct {
+		min, max uint
+		names    map[string]uint
+	}
+)
+
+var (
+	secondBounds = cronBounds{min: 0, max: 59}
+	minuteBounds = cronBounds{min: 0, max: 59}
+	hourBounds   = cronBounds{min: 0, max: 23}
+	domBounds    = cronBounds{min: 1, max: 31}
+	monthBounds  = cronBounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is sunday too.
+	dowBounds = cronBounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// ParseCron parses a cron expression into a Schedule. It accepts:
+//
+//   - 5 fields: minute, hour, day of month, month and day of week,
+//   - 6 fields: second first, then the 5 fields above,
+//   - the descriptors @yearly, @annually, @monthly, @weekly, @daily,
+//     @midnight, @hourly and @every <duration>, like @every 5m.
+//
+// The fields accept *, ?, lists, ranges, steps and the names of the months
+// and the days, like "*/15 9-17 * JAN-MAR MON,FRI". A CRON_TZ=<zone> or
+// TZ=<zone> prefix sets the time zone of the schedule, which is otherwise
+// the location of the time given to Next.
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	var location *time.Location
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid cron spec %q: missing fields", spec)
+		}
+
+		zone := spec[strings.Index(spec, "=")+1 : i]
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		location = loc
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid cron spec %q: non-positive interval", spec)
+		}
+
+		return everySchedule{interval: d}, nil
+	}
+
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if expr, ok = cronDescriptors[spec]; !ok {
+			return nil, fmt.Errorf("invalid cron spec %q: unknown descriptor", spec)
+		}
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	s := &cronSchedule{location: location}
+	var err error
+	for _, f := range []struct {
+		field  string
+		bounds cronBounds
+		bits   *uint64
+	}{
+		{fields[0], secondBounds, &s.second},
+		{fields[1], minuteBounds, &s.minute},
+		{fields[2], hourBounds, &s.hour},
+		{fields[3], domBounds, &s.dom},
+		{fields[4], monthBounds, &s.month},
+		{fields[5], dowBounds, &s.dow},
+	} {
+		if *f.bits, err = parseCronField(f.field, f.bounds); err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+	}
+	// sunday is 0 for time.Weekday.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = isCronStar(fields[3])
+	s.dowStar = isCronStar(fields[5])
+
+	return s, nil
+}
+
+// MustParseCron is like ParseCron but panics if the spec is invalid.
+func MustParseCron(spec string) Schedule {
+	s, err := ParseCron(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+func isCronStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseCronField parses a comma separated list of ranges into a bit set.
+func parseCronField(field string, bounds cronBounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		b, err := parseCronRange(expr, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+
+	return bits, nil
+}
+
+// parseCronRange parses *, ?, a value or a range, with an optional /step.
+func parseCronRange(expr string, bounds cronBounds) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	var start, end uint
+	switch {
+	case rangeExpr == "*" || rangeExpr == "?":
+		start, end = bounds.min, bounds.max
+	default:
+		low, high, isRange := strings.Cut(rangeExpr, "-")
+		var err error
+		if start, err = parseCronValue(low, bounds); err != nil {
+			return 0, err
+		}
+		end = start
+		if isRange {
+			if end, err = parseCronValue(high, bounds); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			// a/n means from a to the max.
+			end = bounds.max
+		}
+	}
+	if start > end {
+		return 0, fmt.Errorf("invalid range %q", expr)
+	}
+
+	step := uint(1)
+	if hasStep {
+		n, err := strconv.ParseUint(stepExpr, 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid step %q", expr)
+		}
+		step = uint(n)
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << v
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(expr string, bounds cronBounds) (uint, error) {
+	if v, ok := bounds.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", expr)
+	}
+	if uint(v) < bounds.min || uint(v) > bounds.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, bounds.min, bounds.max)
+	}
+
+	return uint(v), nil
+}
+
+// Next returns the next time matching the expression after t, in the
+// location of the schedule if any, or of t.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	if s.location != nil {
+		loc = s.location
+	}
+	t = t.In(loc)
+
+	// the activations are on whole seconds.
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + cronSearchYears
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for !matchCron(s.month, uint(t.Month())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.matchDay(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// the midnight may not exist with the daylight saving time.
+		if h := t.Hour(); h != 0 {
+			if h > 12 {
+				t = t.Add(time.Duration(24-h) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(h) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for !matchCron(s.hour, uint(t.Hour())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for !matchCron(s.minute, uint(t.Minute())) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for !matchCron(s.second, uint(t.Second())) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := matchCron(s.dom, uint(t.Day()))
+	dow := matchCron(s.dow, uint(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+func matchCron(bits uint64, v uint) bool {
+	return bits&(1<<v) != 0
+}
+
+// Next returns t plus the interval.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
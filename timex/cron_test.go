@@ -0,0 +1,95 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	// a monday.
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat", time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)},
+		{"0 0 0 1 JAN-MAR/2 ?", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"5,10 1-3/2 * * *", time.Date(2024, 1, 1, 1, 5, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// the day of month or the day of week.
+		{"0 0 1 * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, 1, 1, 0, 1, 30, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			s, err := ParseCron(test.spec)
+			assert.Nil(t, err)
+			assert.True(t, test.next.Equal(s.Next(from)), s.Next(from))
+		})
+	}
+}
+
+func TestParseCronLocation(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := MustParseCron("CRON_TZ=Asia/Tokyo 0 9 * * *")
+	next := s.Next(from)
+	assert.Equal(t, "Asia/Tokyo", next.Location().String())
+	assert.True(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Equal(next), next)
+
+	// the location of the time otherwise.
+	zone := time.FixedZone("UTC+1", 3600)
+	next = MustParseCron("0 9 * * *").Next(from.In(zone))
+	assert.True(t, time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC).Equal(next), next)
+}
+
+func TestParseCronDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// 2am does not exist on 2024-03-10.
+	s := MustParseCron("0 30 2 * * *")
+	next := s.Next(time.Date(2024, 3, 9, 3, 0, 0, 0, loc))
+	assert.Equal(t, 11, next.Day(), next)
+	next = MustParseCron("@daily").Next(time.Date(2024, 3, 9, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, loc), next)
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * FOO *",
+		"@foo",
+		"@every -1s",
+		"@every soon",
+		"TZ=Nowhere/Zone * * * * *",
+		"CRON_TZ=UTC",
+	} {
+		_, err := ParseCron(spec)
+		assert.NotNil(t, err, spec)
+	}
+
+	assert.Panics(t, func() {
+		MustParseCron("@foo")
+	})
+}
@@ -0,0 +1,199 @@
+package timex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A ConfigDuration is a time.Duration for the config files, it is marshalled
+// in the compact format, like 1d2h3.4s, and unmarshalled by ParseDuration, or
+// from an integer of nanoseconds.
+type ConfigDuration time.Duration
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond, // U+00B5 micro sign
+	"μs": time.Microsecond, // U+03BC greek small letter mu
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+	"w":  Week,
+}
+
+// ParseDuration parses a duration like time.ParseDuration, it also accepts
+// the days and the weeks, like 3d or 1w2d12h.
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	var total uint64
+	for s != "" {
+		// the number, with an optional fraction.
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		number := s[:i]
+		s = s[i:]
+		if number == "" || number == "." {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		// the unit, up to the next number.
+		i = 0
+		for i < len(s) && s[i] != '.' && (s[i] < '0' || s[i] > '9') {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("missing unit in duration %q", orig)
+		}
+		unit, ok := durationUnits[s[:i]]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in duration %q", s[:i], orig)
+		}
+		s = s[i:]
+
+		v, err := parseDurationNumber(number, unit)
+		if err != nil || total+v < total || total+v > 1<<63 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		total += v
+	}
+
+	if neg {
+		return -time.Duration(total), nil
+	}
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	return time.Duration(total), nil
+}
+
+// parseDurationNumber returns number times unit in nanoseconds, the digits
+// of the fraction beyond the nanoseconds are dropped.
+func parseDurationNumber(number string, unit time.Duration) (uint64, error) {
+	whole, frac := number, ""
+	for i := 0; i < len(number); i++ {
+		if number[i] == '.' {
+			whole, frac = number[:i], number[i+1:]
+			break
+		}
+	}
+
+	var v uint64
+	if whole != "" {
+		w, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if w > (1<<63)/uint64(unit) {
+			return 0, strconv.ErrRange
+		}
+		v = w * uint64(unit)
+	}
+
+	scale := uint64(unit)
+	var f uint64
+	for i := 0; i < len(frac); i++ {
+		if frac[i] < '0' || frac[i] > '9' {
+			return 0, strconv.ErrSyntax
+		}
+		if scale < 10 {
+			break
+		}
+		scale /= 10
+		f += uint64(frac[i]-'0') * scale
+	}
+
+	return v + f, nil
+}
+
+// Std returns d as a time.Duration.
+func (d ConfigDuration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String returns d in the compact format, like 1d2h3.4s.
+func (d ConfigDuration) String() string {
+	return FormatDuration(time.Duration(d), WithUnit(UnitCompact))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d ConfigDuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *ConfigDuration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = ConfigDuration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d ConfigDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts a string or an
+// integer of nanoseconds.
+func (d *ConfigDuration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(s))
+	}
+
+	var ns int64
+	if err := json.Unmarshal(data, &ns); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = ConfigDuration(ns)
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (d ConfigDuration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, it accepts a string or an
+// integer of nanoseconds.
+func (d *ConfigDuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case int:
+		*d = ConfigDuration(v)
+		return nil
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+}
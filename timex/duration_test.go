@@ -0,0 +1,91 @@
+package timex
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s      string
+		expect time.Duration
+	}{
+		{"0", 0},
+		{"-0", 0},
+		{"1ns", time.Nanosecond},
+		{"1.5us", 1500 * time.Nanosecond},
+		{"1.5µs", 1500 * time.Nanosecond},
+		{"1.5μs", 1500 * time.Nanosecond},
+		{".5s", 500 * time.Millisecond},
+		{"5.s", 5 * time.Second},
+		{"1h2m3.4s", time.Hour + 2*time.Minute + 3400*time.Millisecond},
+		{"3d", 3 * Day},
+		{"1w2d", 9 * Day},
+		{"-1.5d", -36 * time.Hour},
+		{"+2w", 2 * Week},
+		{"1.0000000001s", time.Second},
+		{"9223372036854775807ns", math.MaxInt64},
+		{"-9223372036854775808ns", math.MinInt64},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDuration(test.s)
+		assert.Nil(t, err, test.s)
+		assert.Equal(t, test.expect, d, test.s)
+	}
+
+	for _, s := range []string{
+		"", "-", "s", "1", "1x", "..s", "1.2.3s", "3 d",
+		"9223372036854775808ns", "15251w", "-9223372036854775809ns",
+	} {
+		_, err := ParseDuration(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestConfigDurationRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Nanosecond, 1500 * time.Microsecond,
+		-90 * time.Second, 9*Day + 3*time.Hour + 1234*time.Millisecond, math.MaxInt64} {
+		parsed, err := ParseDuration(ConfigDuration(d).String())
+		assert.Nil(t, err)
+		assert.Equal(t, d, parsed)
+	}
+}
+
+func TestConfigDurationMarshal(t *testing.T) {
+	type config struct {
+		Timeout  ConfigDuration `json:"timeout" yaml:"timeout"`
+		Interval ConfigDuration `json:"interval" yaml:"interval"`
+	}
+
+	var c config
+	assert.Nil(t, json.Unmarshal([]byte(`{"timeout":"1w2d","interval":1000}`), &c))
+	assert.Equal(t, 9*Day, c.Timeout.Std())
+	assert.Equal(t, time.Microsecond, c.Interval.Std())
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"timeout":"9d","interval":"1µs"}`, string(data))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"timeout":"1y"}`), &c))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"timeout":true}`), &c))
+
+	c = config{}
+	assert.Nil(t, yaml.Unmarshal([]byte("timeout: 1h30m\ninterval: 5\n"), &c))
+	assert.Equal(t, 90*time.Minute, c.Timeout.Std())
+	assert.Equal(t, 5*time.Nanosecond, c.Interval.Std())
+	data, err = yaml.Marshal(c)
+	assert.Nil(t, err)
+	assert.Equal(t, "timeout: 1h30m\ninterval: 5ns\n", string(data))
+	assert.NotNil(t, yaml.Unmarshal([]byte("timeout: 3x\n"), &c))
+	assert.NotNil(t, yaml.Unmarshal([]byte("timeout: [1]\n"), &c))
+
+	var d ConfigDuration
+	assert.Nil(t, d.UnmarshalText([]byte("2m")))
+	text, err := d.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "2m", string(text))
+}
@@ -0,0 +1,261 @@
+package timex
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Day and Week are the units of ParseDuration beyond time.Hour, they ignore
+// the daylight saving time.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+const (
+	// UnitAuto formats in the largest unit not greater than the duration,
+	// up to seconds, like time.Duration.String below one second.
+	UnitAuto Unit = iota
+	// UnitNanosecond formats in nanoseconds.
+	UnitNanosecond
+	// UnitMicrosecond formats in microseconds.
+	UnitMicrosecond
+	// UnitMillisecond formats in milliseconds.
+	UnitMillisecond
+	// UnitSecond formats in seconds.
+	UnitSecond
+	// UnitCompact formats in days, hours, minutes and seconds, like 1d2h3.4s,
+	// the zero units are omitted, and the durations below one second are
+	// formatted like UnitAuto.
+	UnitCompact
+)
+
+type (
+	// Unit is the unit of FormatDuration.
+	Unit int
+
+	// FormatOption customizes FormatDuration.
+	FormatOption func(*formatOptions)
+
+	formatOptions struct {
+		unit      Unit
+		precision int
+	}
+
+	humanUnit struct {
+		d    time.Duration
+		name string
+	}
+)
+
+var humanUnits = []humanUnit{
+	{365 * Day, "year"},
+	{30 * Day, "month"},
+	{Week, "week"},
+	{Day, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+}
+
+// WithUnit sets the unit of FormatDuration, defaults to UnitAuto.
+func WithUnit(unit Unit) FormatOption {
+	return func(o *formatOptions) {
+		o.unit = unit
+	}
+}
+
+// WithPrecision sets the number of digits after the decimal point, rounded
+// half away from zero, defaults to -1 which means as many as needed.
+func WithPrecision(precision int) FormatOption {
+	return func(o *formatOptions) {
+		o.precision = precision
+	}
+}
+
+// FormatDuration returns the string representation of d, which is exact,
+// unlike ReprOfDuration, with the default precision.
+func FormatDuration(d time.Duration, opts ...FormatOption) string {
+	o := formatOptions{
+		unit:      UnitAuto,
+		precision: -1,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch o.unit {
+	case UnitNanosecond:
+		return formatInUnit(d, time.Nanosecond, "ns", o.precision)
+	case UnitMicrosecond:
+		return formatInUnit(d, time.Microsecond, "µs", o.precision)
+	case UnitMillisecond:
+		return formatInUnit(d, time.Millisecond, "ms", o.precision)
+	case UnitSecond:
+		return formatInUnit(d, time.Second, "s", o.precision)
+	case UnitCompact:
+		return formatCompact(d, o.precision)
+	default:
+		return formatAuto(d, o.precision)
+	}
+}
+
+func formatAuto(d time.Duration, precision int) string {
+	abs := absDuration(d)
+	switch {
+	case abs < time.Microsecond:
+		return formatInUnit(d, time.Nanosecond, "ns", precision)
+	case abs < time.Millisecond:
+		return formatInUnit(d, time.Microsecond, "µs", precision)
+	case abs < time.Second:
+		return formatInUnit(d, time.Millisecond, "ms", precision)
+	default:
+		return formatInUnit(d, time.Second, "s", precision)
+	}
+}
+
+func formatCompact(d time.Duration, precision int) string {
+	// rounds first, so that the seconds carry into the minutes.
+	if precision >= 0 && precision < 9 {
+		if rounded := d.Round(time.Duration(pow10(9 - precision))); absDuration(rounded) >= time.Second {
+			d = rounded
+		}
+	}
+	abs := absDuration(d)
+	if abs < time.Second {
+		return formatAuto(d, precision)
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+	}
+	for _, u := range []struct {
+		d      time.Duration
+		symbol string
+	}{
+		{Day, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+	} {
+		if n := abs / u.d; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(u.symbol)
+			abs -= n * u.d
+		}
+	}
+	if abs > 0 {
+		b.WriteString(formatInUnit(abs, time.Second, "s", precision))
+	}
+
+	return b.String()
+}
+
+// formatInUnit returns d in unit, with precision digits after the decimal
+// point, the digits are exact as they come from integers.
+func formatInUnit(d, unit time.Duration, symbol string, precision int) string {
+	neg := d < 0
+	// -d overflows for math.MinInt64, uint64 does not.
+	u := uint64(d)
+	if neg {
+		u = -u
+	}
+
+	digits := 0
+	for p := unit; p > 1; p /= 10 {
+		digits++
+	}
+	whole, frac := u/uint64(unit), u%uint64(unit)
+	if precision >= 0 && precision < digits {
+		scale := pow10(digits - precision)
+		frac = (frac + scale/2) / scale
+		if frac >= pow10(precision) {
+			whole++
+			frac -= pow10(precision)
+		}
+		digits = precision
+	}
+
+	var b strings.Builder
+	if neg && (whole != 0 || frac != 0) {
+		b.WriteByte('-')
+	}
+	b.WriteString(strconv.FormatUint(whole, 10))
+
+	fraction := ""
+	if digits > 0 {
+		fraction = strconv.FormatUint(frac, 10)
+		fraction = strings.Repeat("0", digits-len(fraction)) + fraction
+	}
+	if precision < 0 {
+		fraction = strings.TrimRight(fraction, "0")
+	} else if precision > digits {
+		fraction += strings.Repeat("0", precision-digits)
+	}
+	if fraction != "" {
+		b.WriteByte('.')
+		b.WriteString(fraction)
+	}
+	b.WriteString(symbol)
+
+	return b.String()
+}
+
+// HumanizeDuration returns d in its largest whole unit from seconds to
+// years, like "3 minutes", a month is 30 days and a year 365 days.
+func HumanizeDuration(d time.Duration) string {
+	abs := absDuration(d)
+	for _, u := range humanUnits {
+		if n := int64(abs / u.d); n > 0 || u.d == time.Second {
+			name := u.name
+			if n != 1 {
+				name += "s"
+			}
+			return strconv.FormatInt(n, 10) + " " + name
+		}
+	}
+
+	return ""
+}
+
+// Relative returns the humanized time of t relative to now, like
+// "3 minutes ago" or "in 2 days".
+func Relative(t time.Time) string {
+	return RelativeTo(t, time.Now())
+}
+
+// RelativeTo returns the humanized time of t relative to now, like
+// "3 minutes ago" or "in 2 days", or "just now" within a second.
+func RelativeTo(t, now time.Time) string {
+	d := t.Sub(now)
+	switch {
+	case absDuration(d) < time.Second:
+		return "just now"
+	case d < 0:
+		return HumanizeDuration(d) + " ago"
+	default:
+		return "in " + HumanizeDuration(d)
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		// math.MinInt64 has no positive counterpart.
+		if d == -d {
+			return 1<<63 - 1
+		}
+		return -d
+	}
+
+	return d
+}
+
+func pow10(n int) uint64 {
+	p := uint64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
@@ -0,0 +1,84 @@
+package timex
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d      time.Duration
+		opts   []FormatOption
+		expect string
+	}{
+		{0, nil, "0ns"},
+		{999, nil, "999ns"},
+		{1500, nil, "1.5µs"},
+		{1500 * time.Microsecond, nil, "1.5ms"},
+		{-1500 * time.Microsecond, nil, "-1.5ms"},
+		{90 * time.Minute, nil, "5400s"},
+		{1234567 * time.Nanosecond, []FormatOption{WithPrecision(2)}, "1.23ms"},
+		{1235 * time.Microsecond, []FormatOption{WithPrecision(2)}, "1.24ms"},
+		{time.Second, []FormatOption{WithPrecision(3)}, "1.000s"},
+		{1999500 * time.Microsecond, []FormatOption{WithPrecision(0)}, "2s"},
+		{time.Second, []FormatOption{WithUnit(UnitMillisecond)}, "1000ms"},
+		{time.Millisecond, []FormatOption{WithUnit(UnitNanosecond), WithPrecision(1)}, "1000000.0ns"},
+		{1500 * time.Nanosecond, []FormatOption{WithUnit(UnitMicrosecond)}, "1.5µs"},
+		{time.Millisecond, []FormatOption{WithUnit(UnitSecond)}, "0.001s"},
+		{-time.Nanosecond, []FormatOption{WithUnit(UnitSecond), WithPrecision(3)}, "0.000s"},
+		// long durations do not lose precision.
+		{1000*time.Hour + time.Nanosecond, []FormatOption{WithUnit(UnitMillisecond)}, "3600000000.000001ms"},
+		{time.Duration(math.MaxInt64), []FormatOption{WithUnit(UnitSecond)}, "9223372036.854775807s"},
+		{time.Duration(math.MinInt64), []FormatOption{WithUnit(UnitSecond)}, "-9223372036.854775808s"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, FormatDuration(test.d, test.opts...))
+	}
+}
+
+func TestFormatDurationCompact(t *testing.T) {
+	compact := WithUnit(UnitCompact)
+	tests := []struct {
+		d         time.Duration
+		precision int
+		expect    string
+	}{
+		{0, -1, "0ns"},
+		{1500 * time.Microsecond, -1, "1.5ms"},
+		{time.Second, -1, "1s"},
+		{time.Hour + 2*time.Minute + 3400*time.Millisecond, -1, "1h2m3.4s"},
+		{time.Hour + 3*time.Second, -1, "1h3s"},
+		{26 * time.Hour, -1, "1d2h"},
+		{-90 * time.Second, -1, "-1m30s"},
+		{time.Minute + 3456*time.Millisecond, 1, "1m3.5s"},
+		{time.Minute + 3*time.Second, 1, "1m3.0s"},
+		// the seconds carry.
+		{59999 * time.Millisecond, 1, "1m"},
+		{999600 * time.Microsecond, 0, "1s"},
+		{40 * time.Millisecond, 1, "40.0ms"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, FormatDuration(test.d, compact, WithPrecision(test.precision)), test.d)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	assert.Equal(t, "0 seconds", HumanizeDuration(0))
+	assert.Equal(t, "1 second", HumanizeDuration(time.Second))
+	assert.Equal(t, "1 minute", HumanizeDuration(-90*time.Second))
+	assert.Equal(t, "3 hours", HumanizeDuration(3*time.Hour))
+	assert.Equal(t, "2 weeks", HumanizeDuration(15*Day))
+	assert.Equal(t, "1 month", HumanizeDuration(45*Day))
+	assert.Equal(t, "2 years", HumanizeDuration(800*Day))
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "just now", RelativeTo(now.Add(500*time.Millisecond), now))
+	assert.Equal(t, "3 minutes ago", RelativeTo(now.Add(-3*time.Minute), now))
+	assert.Equal(t, "in 2 days", RelativeTo(now.Add(2*Day+time.Hour), now))
+	assert.Equal(t, "1 hour ago", Relative(time.Now().Add(-time.Hour-time.Minute)))
+}
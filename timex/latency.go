@@ -0,0 +1,182 @@
+package timex
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// histogramSubBits is the number of significant bits kept by the histogram,
+// the relative error of the quantiles is below 1/2^histogramSubBits.
+const histogramSubBits = 7
+
+type (
+	// A LatencyRecorder reports the quantiles of the latencies recorded in the
+	// last size intervals, with a relative error below 1%.
+	//
+	// Each interval keeps a log-linear histogram, like the HDR histograms, so
+	// that recording is O(1) and the memory does not grow with the latencies.
+	LatencyRecorder struct {
+		mu      sync.Mutex
+		ring    *ring
+		buckets []latencyBucket
+	}
+
+	// LatencySnapshot is a snapshot of the latencies of a LatencyRecorder.
+	LatencySnapshot struct {
+		Count int64
+		Min   time.Duration
+		Max   time.Duration
+		Mean  time.Duration
+		P50   time.Duration
+		P90   time.Duration
+		P99   time.Duration
+	}
+
+	latencyBucket struct {
+		stats  Bucket
+		counts map[uint16]int64
+	}
+)
+
+// NewLatencyRecorder returns a LatencyRecorder of size intervals.
+func NewLatencyRecorder(size int, interval time.Duration) *LatencyRecorder {
+	return NewLatencyRecorderWithClock(NewRealClock(), size, interval)
+}
+
+// NewLatencyRecorderWithClock returns a LatencyRecorder driven by clock.
+func NewLatencyRecorderWithClock(clock Clock, size int, interval time.Duration) *LatencyRecorder {
+	lr := &LatencyRecorder{
+		buckets: make([]latencyBucket, size),
+	}
+	lr.ring = newRing(clock, size, interval, func(i int) {
+		lr.buckets[i] = latencyBucket{}
+	})
+
+	return lr
+}
+
+// Record records the latency d, negative latencies are recorded as zero.
+func (lr *LatencyRecorder) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	b := &lr.buckets[lr.ring.current()]
+	if b.counts == nil {
+		b.counts = make(map[uint16]int64)
+	}
+	b.stats.add(float64(d))
+	b.counts[histogramIndex(uint64(d))]++
+}
+
+// Observe records the duration of et.
+func (lr *LatencyRecorder) Observe(et *ElapsedTimer) {
+	lr.Record(et.Duration())
+}
+
+// Quantile returns the q quantile of the latencies, q is in [0, 1].
+func (lr *LatencyRecorder) Quantile(q float64) time.Duration {
+	_, qs := lr.quantiles(q)
+	return qs[0]
+}
+
+// Snapshot returns the count, min, max, mean, p50, p90 and p99 of the latencies.
+func (lr *LatencyRecorder) Snapshot() LatencySnapshot {
+	stats, qs := lr.quantiles(0.5, 0.9, 0.99)
+	return LatencySnapshot{
+		Count: stats.Count,
+		Min:   time.Duration(stats.Min),
+		Max:   time.Duration(stats.Max),
+		Mean:  time.Duration(stats.Mean()),
+		P50:   qs[0],
+		P90:   qs[1],
+		P99:   qs[2],
+	}
+}
+
+// quantiles returns the aggregate and the qs quantiles of the latencies.
+func (lr *LatencyRecorder) quantiles(qs ...float64) (Bucket, []time.Duration) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	var stats Bucket
+	counts := make(map[uint16]int64)
+	lr.ring.each(func(i int) {
+		b := &lr.buckets[i]
+		stats.merge(&b.stats)
+		for index, count := range b.counts {
+			counts[index] += count
+		}
+	})
+
+	out := make([]time.Duration, len(qs))
+	if stats.Count == 0 {
+		return stats, out
+	}
+
+	indexes := make([]uint16, 0, len(counts))
+	for index := range counts {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+
+	for i, q := range qs {
+		// the extremes are known exactly.
+		if q <= 0 {
+			out[i] = time.Duration(stats.Min)
+			continue
+		}
+		if q >= 1 {
+			out[i] = time.Duration(stats.Max)
+			continue
+		}
+
+		rank := int64(math.Ceil(q * float64(stats.Count)))
+		var seen int64
+		for _, index := range indexes {
+			seen += counts[index]
+			if seen >= rank {
+				v := math.Min(math.Max(float64(histogramValue(index)), stats.Min), stats.Max)
+				out[i] = time.Duration(v)
+				break
+			}
+		}
+	}
+
+	return stats, out
+}
+
+// histogramIndex returns the histogram slot of v, the values below
+// 2^histogramSubBits have their own slots, the others share slots of the
+// same top histogramSubBits+1 bits.
+func histogramIndex(v uint64) uint16 {
+	const sub = 1 << histogramSubBits
+	if v < sub {
+		return uint16(v)
+	}
+
+	shift := bits.Len64(v) - histogramSubBits - 1
+	mantissa := v >> uint(shift)
+	return uint16((shift+1)*sub) + uint16(mantissa-sub)
+}
+
+// histogramValue returns the middle of the values of the histogram slot index.
+func histogramValue(index uint16) uint64 {
+	const sub = 1 << histogramSubBits
+	if index < sub {
+		return uint64(index)
+	}
+
+	shift := uint(index/sub - 1)
+	mantissa := uint64(index%sub + sub)
+	low := mantissa << shift
+	return low + (uint64(1)<<shift)/2
+}
@@ -0,0 +1,77 @@
+package timex
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramIndex(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 255, 256, 1000, 123456789, 1<<63 - 1} {
+		got := histogramValue(histogramIndex(v))
+		assert.InDelta(t, float64(v), float64(got), float64(v)/(1<<histogramSubBits)+0.5, v)
+	}
+
+	// the indexes keep the order of the values.
+	var prev uint16
+	for v := uint64(1); v < 1<<20; v += 37 {
+		index := histogramIndex(v)
+		assert.True(t, index >= prev, v)
+		prev = index
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	lr := NewLatencyRecorderWithClock(clock, 10, time.Second)
+	assert.Equal(t, LatencySnapshot{}, lr.Snapshot())
+
+	for _, i := range rand.Perm(1000) {
+		lr.Record(time.Duration(i+1) * time.Millisecond)
+	}
+
+	snapshot := lr.Snapshot()
+	assert.Equal(t, int64(1000), snapshot.Count)
+	assert.Equal(t, time.Millisecond, snapshot.Min)
+	assert.Equal(t, time.Second, snapshot.Max)
+	assert.InDelta(t, float64(500500*time.Microsecond), float64(snapshot.Mean), float64(time.Microsecond))
+	assertLatency(t, 500*time.Millisecond, snapshot.P50)
+	assertLatency(t, 900*time.Millisecond, snapshot.P90)
+	assertLatency(t, 990*time.Millisecond, snapshot.P99)
+	assert.Equal(t, time.Millisecond, lr.Quantile(0))
+	assert.Equal(t, time.Second, lr.Quantile(1))
+
+	// the latencies leave the window.
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, LatencySnapshot{}, lr.Snapshot())
+}
+
+func TestLatencyRecorderObserve(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	lr := NewLatencyRecorderWithClock(clock, 10, time.Second)
+
+	et := NewElapsedTimerWithClock(clock)
+	clock.Advance(30 * time.Millisecond)
+	lr.Observe(et)
+	lr.Record(-time.Second)
+
+	snapshot := lr.Snapshot()
+	assert.Equal(t, int64(2), snapshot.Count)
+	assert.Equal(t, time.Duration(0), snapshot.Min)
+	assert.Equal(t, 30*time.Millisecond, snapshot.Max)
+}
+
+func assertLatency(t *testing.T, expected, actual time.Duration) {
+	t.Helper()
+	assert.InEpsilon(t, float64(expected), float64(actual), 0.01)
+}
+
+func BenchmarkLatencyRecorder(b *testing.B) {
+	lr := NewLatencyRecorder(10, time.Second)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lr.Record(time.Duration(i))
+	}
+}
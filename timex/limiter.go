@@ -0,0 +1,392 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrLimitExceeded is returned by Wait if the tokens can not be available
+// in time, or more tokens than the burst are requested.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+type (
+	// Limiter interface wraps the AllowN method of the rate limiters.
+	Limiter interface {
+		// AllowN reports whether n events may happen now, and consumes them if so.
+		AllowN(n int) bool
+	}
+
+	// A TokenLimiter is a token bucket rate limiter, which refills rate tokens
+	// per second up to burst tokens.
+	TokenLimiter struct {
+		clock Clock
+		rate  float64
+		burst int
+
+		mu     sync.Mutex
+		tokens float64
+		last   time.Time
+	}
+
+	// A Reservation holds tokens of a TokenLimiter, which can be used after Delay.
+	Reservation struct {
+		limiter *TokenLimiter
+		ok      bool
+		tokens  int
+		act     time.Time
+	}
+
+	// A SlidingWindowLimiter allows limit events in any window, it weights the
+	// count of the previous window by its overlap with the sliding window.
+	SlidingWindowLimiter struct {
+		clock  Clock
+		limit  int
+		window time.Duration
+
+		mu        sync.Mutex
+		start     time.Time
+		count     int
+		prevCount int
+	}
+
+	// A KeyedLimiter holds a Limiter per key, like a tenant or an ip, the
+	// limiters of the keys idle for longer than idle are evicted.
+	KeyedLimiter struct {
+		clock      Clock
+		newLimiter func() Limiter
+		idle       time.Duration
+
+		mu        sync.Mutex
+		limiters  map[string]*keyedLimiter
+		lastSweep time.Time
+	}
+
+	keyedLimiter struct {
+		limiter  Limiter
+		lastSeen time.Time
+	}
+)
+
+// NewTokenLimiter returns a TokenLimiter refilling rate tokens per second up
+// to burst tokens, the bucket starts full.
+func NewTokenLimiter(rate float64, burst int) *TokenLimiter {
+	return NewTokenLimiterWithClock(NewRealClock(), rate, burst)
+}
+
+// NewTokenLimiterWithClock returns a TokenLimiter driven by clock.
+func NewTokenLimiterWithClock(clock Clock, rate float64, burst int) *TokenLimiter {
+	return &TokenLimiter{
+		clock:  clock,
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Allow reports whether an event may happen now.
+func (l *TokenLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, and consumes their tokens if so.
+// A non-positive n is always allowed and consumes nothing.
+func (l *TokenLimiter) AllowN(n int) bool {
+	return l.reserveN(l.clock.Now(), n, 0).ok
+}
+
+// Reserve returns a Reservation of a token, see ReserveN.
+func (l *TokenLimiter) Reserve() *Reservation {
+	return l.ReserveN(1)
+}
+
+// ReserveN reserves n tokens, which are available after the Delay of the
+// Reservation. The Reservation is not OK if n exceeds the burst.
+func (l *TokenLimiter) ReserveN(n int) *Reservation {
+	return l.reserveN(l.clock.Now(), n, time.Duration(math.MaxInt64))
+}
+
+// Wait blocks until a token is available, see WaitN.
+func (l *TokenLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available, it returns ErrLimitExceeded
+// without waiting if n exceeds the burst or the tokens come after the
+// deadline of ctx.
+func (l *TokenLimiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := l.clock.Now()
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		// the deadlines of contexts are on the real clock.
+		maxWait = time.Until(deadline)
+	}
+	r := l.reserveN(now, n, maxWait)
+	if !r.ok {
+		return ErrLimitExceeded
+	}
+
+	delay := r.act.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := l.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of available tokens, negative if reserved ahead.
+func (l *TokenLimiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.advance(l.clock.Now())
+}
+
+func (l *TokenLimiter) reserveN(now time.Time, n int, maxWait time.Duration) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// no tokens are needed, negative ones must not fill the bucket.
+	if n <= 0 {
+		return &Reservation{limiter: l, ok: true, act: now}
+	}
+	if n > l.burst {
+		return &Reservation{limiter: l}
+	}
+
+	tokens := l.advance(now) - float64(n)
+	var wait time.Duration
+	if tokens < 0 {
+		if l.rate <= 0 {
+			return &Reservation{limiter: l}
+		}
+		wait = time.Duration(math.Ceil(-tokens / l.rate * float64(time.Second)))
+	}
+	if wait > maxWait {
+		return &Reservation{limiter: l}
+	}
+
+	l.tokens = tokens
+	l.last = now
+	return &Reservation{
+		limiter: l,
+		ok:      true,
+		tokens:  n,
+		act:     now.Add(wait),
+	}
+}
+
+// advance returns the tokens at now, the clock going backwards adds none.
+func (l *TokenLimiter) advance(now time.Time) float64 {
+	elapsed := now.Sub(l.last)
+	if elapsed <= 0 {
+		return l.tokens
+	}
+
+	tokens := l.tokens + elapsed.Seconds()*l.rate
+	if burst := float64(l.burst); tokens > burst {
+		tokens = burst
+	}
+	return tokens
+}
+
+// OK reports whether the tokens are reserved.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns the time to wait before using the tokens.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return time.Duration(math.MaxInt64)
+	}
+
+	if delay := r.act.Sub(r.limiter.clock.Now()); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// Cancel gives the tokens back if they are not usable yet.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if !r.act.After(now) {
+		return
+	}
+	l.tokens = l.advance(now) + float64(r.tokens)
+	if burst := float64(l.burst); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+	r.ok = false
+}
+
+// NewSlidingWindowLimiter returns a SlidingWindowLimiter allowing limit
+// events per window.
+func NewSlidingWindowLimiter(limit int, window time.Duration) *SlidingWindowLimiter {
+	return NewSlidingWindowLimiterWithClock(NewRealClock(), limit, window)
+}
+
+// NewSlidingWindowLimiterWithClock returns a SlidingWindowLimiter driven by clock.
+func NewSlidingWindowLimiterWithClock(clock Clock, limit int, window time.Duration) *SlidingWindowLimiter {
+	if window <= 0 {
+		panic("non-positive window for NewSlidingWindowLimiter")
+	}
+
+	return &SlidingWindowLimiter{
+		clock:  clock,
+		limit:  limit,
+		window: window,
+		start:  clock.Now(),
+	}
+}
+
+// Allow reports whether an event may happen now.
+func (l *SlidingWindowLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, and counts them if so.
+// A non-positive n is always allowed and counts nothing.
+func (l *SlidingWindowLimiter) AllowN(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.slide(now)
+	if l.estimate(now)+float64(n) > float64(l.limit) {
+		return false
+	}
+
+	l.count += n
+	return true
+}
+
+// Count returns the weighted number of events in the sliding window.
+func (l *SlidingWindowLimiter) Count() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.slide(now)
+	return l.estimate(now)
+}
+
+func (l *SlidingWindowLimiter) slide(now time.Time) {
+	elapsed := now.Sub(l.start)
+	if elapsed < l.window {
+		return
+	}
+
+	windows := elapsed / l.window
+	if windows == 1 {
+		l.prevCount = l.count
+	} else {
+		l.prevCount = 0
+	}
+	l.count = 0
+	l.start = l.start.Add(windows * l.window)
+}
+
+func (l *SlidingWindowLimiter) estimate(now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(l.start))/float64(l.window)
+	if overlap < 0 {
+		overlap = 0
+	}
+
+	return float64(l.prevCount)*overlap + float64(l.count)
+}
+
+// NewKeyedLimiter returns a KeyedLimiter creating the Limiter of a key with
+// newLimiter, the limiters unused for idle are evicted lazily, at most once
+// per idle.
+func NewKeyedLimiter(newLimiter func() Limiter, idle time.Duration) *KeyedLimiter {
+	return NewKeyedLimiterWithClock(NewRealClock(), newLimiter, idle)
+}
+
+// NewKeyedLimiterWithClock returns a KeyedLimiter driven by clock.
+func NewKeyedLimiterWithClock(clock Clock, newLimiter func() Limiter, idle time.Duration) *KeyedLimiter {
+	return &KeyedLimiter{
+		clock:      clock,
+		newLimiter: newLimiter,
+		idle:       idle,
+		limiters:   make(map[string]*keyedLimiter),
+		lastSweep:  clock.Now(),
+	}
+}
+
+// Allow reports whether an event of key may happen now.
+func (l *KeyedLimiter) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of key may happen now.
+func (l *KeyedLimiter) AllowN(key string, n int) bool {
+	return l.Limiter(key).AllowN(n)
+}
+
+// Limiter returns the Limiter of key, it is created if absent.
+func (l *KeyedLimiter) Limiter(key string) Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &keyedLimiter{limiter: l.newLimiter()}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	return entry.limiter
+}
+
+// Len returns the number of keys with a Limiter.
+func (l *KeyedLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(l.clock.Now())
+	return len(l.limiters)
+}
+
+// sweep evicts the idle limiters at most once per idle.
+func (l *KeyedLimiter) sweep(now time.Time) {
+	if l.idle <= 0 || now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+
+	for key, entry := range l.limiters {
+		if now.Sub(entry.lastSeen) >= l.idle {
+			delete(l.limiters, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,184 @@
+package timex
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenLimiterAllow(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewTokenLimiterWithClock(clock, 10, 5)
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// the bucket does not exceed the burst.
+	clock.Advance(time.Hour)
+	assert.Equal(t, 5.0, l.Tokens())
+	assert.True(t, l.AllowN(5))
+	assert.False(t, l.AllowN(6))
+}
+
+func TestTokenLimiterReserve(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewTokenLimiterWithClock(clock, 2, 2)
+
+	assert.True(t, l.AllowN(2))
+	r := l.Reserve()
+	assert.True(t, r.OK())
+	assert.Equal(t, 500*time.Millisecond, r.Delay())
+	r2 := l.ReserveN(2)
+	assert.Equal(t, 1500*time.Millisecond, r2.Delay())
+
+	// cancelling gives the tokens back.
+	r2.Cancel()
+	assert.Equal(t, -1.0, l.Tokens())
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, time.Duration(0), r.Delay())
+	r.Cancel()
+	assert.Equal(t, 0.0, l.Tokens())
+
+	r = l.ReserveN(3)
+	assert.False(t, r.OK())
+	r.Cancel()
+
+	l = NewTokenLimiterWithClock(clock, 0, 1)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Reserve().OK())
+}
+
+func TestTokenLimiterWait(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewTokenLimiterWithClock(clock, 1, 1)
+	assert.Nil(t, l.Wait(context.Background()))
+
+	done := make(chan error)
+	go func() {
+		done <- l.Wait(context.Background())
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Nil(t, <-done)
+
+	assert.Equal(t, ErrLimitExceeded, l.WaitN(context.Background(), 2))
+
+	// the token comes after the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, ErrLimitExceeded, l.Wait(ctx))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		done <- l.Wait(ctx)
+	}()
+	clock.BlockUntil(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, context.Canceled, l.Wait(ctx))
+	// the cancelled wait gave its token back.
+	clock.Advance(time.Second)
+	assert.True(t, l.Allow())
+}
+
+func TestTokenLimiterConcurrent(t *testing.T) {
+	l := NewTokenLimiter(1, 100)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var allowed int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if l.Allow() {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.True(t, allowed >= 100 && allowed <= 101, allowed)
+}
+
+func TestTokenLimiterNonPositiveN(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewTokenLimiterWithClock(clock, 1, 2)
+
+	assert.True(t, l.AllowN(2))
+	// negative tokens must not be credited above the burst.
+	assert.True(t, l.AllowN(-5))
+	assert.True(t, l.AllowN(0))
+	assert.Equal(t, 0.0, l.Tokens())
+	assert.False(t, l.Allow())
+
+	r := l.ReserveN(-1)
+	assert.True(t, r.OK())
+	assert.Equal(t, time.Duration(0), r.Delay())
+	r.Cancel()
+	assert.Equal(t, 0.0, l.Tokens())
+	assert.Nil(t, l.WaitN(context.Background(), 0))
+
+	w := NewSlidingWindowLimiterWithClock(clock, 2, time.Minute)
+	assert.True(t, w.AllowN(2))
+	assert.True(t, w.AllowN(-2))
+	assert.Equal(t, 2.0, w.Count())
+	assert.False(t, w.Allow())
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewSlidingWindowLimiterWithClock(clock, 10, time.Minute)
+
+	assert.True(t, l.AllowN(10))
+	assert.False(t, l.Allow())
+
+	// a quarter into the next window, 75% of the previous window is counted.
+	clock.Advance(75 * time.Second)
+	assert.Equal(t, 7.5, l.Count())
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+
+	clock.Advance(45 * time.Second)
+	assert.Equal(t, 2.0, l.Count())
+
+	// the counts older than two windows are dropped.
+	clock.Advance(3 * time.Minute)
+	assert.Equal(t, 0.0, l.Count())
+	assert.True(t, l.AllowN(10))
+
+	assert.Panics(t, func() {
+		NewSlidingWindowLimiter(1, 0)
+	})
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	l := NewKeyedLimiterWithClock(clock, func() Limiter {
+		return NewTokenLimiterWithClock(clock, 1, 1)
+	}, time.Minute)
+
+	assert.True(t, l.Allow("tenant-a"))
+	assert.False(t, l.Allow("tenant-a"))
+	assert.True(t, l.Allow("tenant-b"))
+	assert.Equal(t, 2, l.Len())
+
+	clock.Advance(30 * time.Second)
+	assert.True(t, l.AllowN("tenant-b", 1))
+	clock.Advance(30 * time.Second)
+	// tenant-a is idle for a minute.
+	assert.Equal(t, 1, l.Len())
+	assert.NotNil(t, l.Limiter("tenant-b"))
+	assert.Equal(t, 1, l.Len())
+}
@@ -0,0 +1,334 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultRetryAttempts     = 3
+	defaultRetryInitialDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay     = 10 * time.Second
+
+	retryEventName = "retry.attempt"
+)
+
+type (
+	// A Backoff returns the delay before the next attempt, attempt is the
+	// number of failed attempts from 1, and prev is the previous delay.
+	Backoff func(attempt int, prev time.Duration) time.Duration
+
+	// RetryOption customizes Retry.
+	RetryOption func(*retryOptions)
+
+	retryOptions struct {
+		backoff        Backoff
+		maxAttempts    int
+		maxElapsedTime time.Duration
+		attemptTimeout time.Duration
+		retryable      func(err error) bool
+		clock          Clock
+	}
+
+	permanentError struct {
+		err error
+	}
+)
+
+// ConstantBackoff returns a Backoff waiting d between the attempts.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// LinearBackoff returns a Backoff waiting initial, then step more on each
+// attempt, up to max.
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		return capDelay(initial+time.Duration(attempt-1)*step, max)
+	}
+}
+
+// ExponentialBackoff returns a Backoff waiting initial, then multiplied by
+// multiplier on each attempt, up to max, zero or negative means no max.
+func ExponentialBackoff(initial, max time.Duration, multiplier float64) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+		// the float may overflow a time.Duration before reaching max.
+		if delay >= math.MaxInt64 {
+			return capDelay(time.Duration(math.MaxInt64), max)
+		}
+
+		return capDelay(time.Duration(delay), max)
+	}
+}
+
+// DecorrelatedJitterBackoff returns a Backoff waiting a random delay between
+// base and three times the previous delay, up to max. It spreads the retries
+// of the clients which failed at the same time.
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+		upper := prev * 3
+		if upper <= base {
+			return capDelay(base, max)
+		}
+
+		return capDelay(base+time.Duration(rand.Int63n(int64(upper-base))), max)
+	}
+}
+
+func capDelay(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+
+	return d
+}
+
+// WithBackoff sets the Backoff between the attempts, defaults to an
+// ExponentialBackoff from 100ms to 10s.
+func WithBackoff(backoff Backoff) RetryOption {
+	return func(o *retryOptions) {
+		o.backoff = backoff
+	}
+}
+
+// WithMaxAttempts sets the maximum number of attempts, defaults to 3,
+// zero or negative means no limit.
+func WithMaxAttempts(n int) RetryOption {
+	return func(o *retryOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithMaxElapsedTime stops retrying once the next attempt would start after
+// d since the first one, zero means no limit.
+func WithMaxElapsedTime(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.maxElapsedTime = d
+	}
+}
+
+// WithAttemptTimeout sets the timeout of the context of each attempt,
+// zero means no timeout.
+func WithAttemptTimeout(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.attemptTimeout = d
+	}
+}
+
+// WithRetryable sets the function reporting whether an error is retryable,
+// the errors wrapped by Permanent are never retried.
+func WithRetryable(retryable func(err error) bool) RetryOption {
+	return func(o *retryOptions) {
+		o.retryable = retryable
+	}
+}
+
+// WithRetryClock sets the Clock timing the retries.
+func WithRetryClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.clock = clock
+	}
+}
+
+// Permanent wraps err so that Retry returns it without retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err is wrapped by Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Retry calls fn until it succeeds, returns a permanent error, or the
+// attempts, the elapsed time or ctx run out. It returns the last error of
+// fn, unwrapped from Permanent, or the error of ctx if fn was never called.
+//
+// An event is added to the span of ctx after every attempt.
+func Retry(ctx context.Context, fn func(ctx context.Context) error, opts ...RetryOption) error {
+	o := retryOptions{
+		backoff:     ExponentialBackoff(defaultRetryInitialDelay, defaultRetryMaxDelay, 2),
+		maxAttempts: defaultRetryAttempts,
+		clock:       NewRealClock(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	start := o.clock.Now()
+	var delay time.Duration
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return err
+		}
+
+		err := o.attempt(ctx, fn)
+		if err == nil {
+			o.addEvent(span, attempt, nil, 0, false)
+			return nil
+		}
+
+		var pe *permanentError
+		if errors.As(err, &pe) {
+			o.addEvent(span, attempt, err, 0, false)
+			return pe.err
+		}
+		lastErr = err
+		if o.retryable != nil && !o.retryable(err) {
+			o.addEvent(span, attempt, err, 0, false)
+			return err
+		}
+		if o.maxAttempts > 0 && attempt >= o.maxAttempts {
+			o.addEvent(span, attempt, err, 0, false)
+			return err
+		}
+
+		delay = o.backoff(attempt, delay)
+		if o.maxElapsedTime > 0 && o.clock.Since(start)+delay > o.maxElapsedTime {
+			o.addEvent(span, attempt, err, 0, false)
+			return err
+		}
+		o.addEvent(span, attempt, err, delay, true)
+
+		if !o.wait(ctx, delay) {
+			return err
+		}
+	}
+}
+
+func (o *retryOptions) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if o.attemptTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := withClockTimeout(ctx, o.clock, o.attemptTimeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// wait waits for delay, it returns false if ctx is done first.
+func (o *retryOptions) wait(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+
+	timer := o.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.Chan():
+		return true
+	}
+}
+
+func (o *retryOptions) addEvent(span trace.Span, attempt int, err error, delay time.Duration, retry bool) {
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.Int("retry.attempt", attempt),
+		attribute.Bool("retry.will_retry", retry),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("retry.error", err.Error()))
+	}
+	if retry {
+		attrs = append(attrs, attribute.Int64("retry.delay_ms", delay.Milliseconds()))
+	}
+	span.AddEvent(retryEventName, trace.WithAttributes(attrs...))
+}
+
+// withClockTimeout returns a context done after d on clock, its error is
+// context.DeadlineExceeded whatever the clock, like context.WithTimeout.
+func withClockTimeout(ctx context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	c := &clockTimeoutCtx{
+		Context: ctx,
+		done:    make(chan struct{}),
+	}
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	timer := clock.NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.Chan():
+			c.cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			c.cancel(ctx.Err())
+		case <-stop:
+			c.cancel(context.Canceled)
+		}
+	}()
+
+	return c, func() {
+		stopOnce.Do(func() {
+			close(stop)
+		})
+		<-c.done
+	}
+}
+
+// clockTimeoutCtx is a context done by the timer of a Clock, it does not
+// embed a cancelable context, so that its children get its own error.
+type clockTimeoutCtx struct {
+	context.Context
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+func (c *clockTimeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *clockTimeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *clockTimeoutCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
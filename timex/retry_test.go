@@ -0,0 +1,249 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errRetry = errors.New("retry")
+
+func TestBackoff(t *testing.T) {
+	constant := ConstantBackoff(time.Second)
+	assert.Equal(t, time.Second, constant(1, 0))
+	assert.Equal(t, time.Second, constant(10, time.Second))
+
+	linear := LinearBackoff(time.Second, 2*time.Second, 6*time.Second)
+	assert.Equal(t, time.Second, linear(1, 0))
+	assert.Equal(t, 3*time.Second, linear(2, time.Second))
+	assert.Equal(t, 5*time.Second, linear(3, 3*time.Second))
+	assert.Equal(t, 6*time.Second, linear(4, 5*time.Second))
+
+	exponential := ExponentialBackoff(100*time.Millisecond, time.Second, 2)
+	var delays []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		delays = append(delays, exponential(attempt, 0))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, delays)
+
+	// zero means no max, like the other backoffs.
+	unbounded := ExponentialBackoff(time.Second, 0, 10)
+	assert.Equal(t, time.Second, unbounded(1, 0))
+	assert.Equal(t, 1000*time.Second, unbounded(4, 0))
+	assert.Equal(t, time.Duration(math.MaxInt64), unbounded(100, 0))
+
+	jitter := DecorrelatedJitterBackoff(100*time.Millisecond, 2*time.Second)
+	var prev time.Duration
+	for attempt := 1; attempt <= 100; attempt++ {
+		d := jitter(attempt, prev)
+		assert.True(t, d >= 100*time.Millisecond && d <= 2*time.Second, d)
+		if prev > 0 {
+			assert.True(t, d <= 3*prev, d)
+		}
+		prev = d
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls int
+	err := Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errRetry
+		}
+		return nil
+	}, WithBackoff(ConstantBackoff(0)))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errRetry
+	}, WithBackoff(ConstantBackoff(0)), WithMaxAttempts(5))
+	assert.Equal(t, errRetry, err)
+	assert.Equal(t, 5, calls)
+}
+
+func TestRetryPermanent(t *testing.T) {
+	var calls int
+	err := Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return Permanent(errRetry)
+	})
+	assert.Equal(t, errRetry, err)
+	assert.Equal(t, 1, calls)
+	assert.True(t, IsPermanent(Permanent(errRetry)))
+	assert.False(t, IsPermanent(errRetry))
+	assert.Nil(t, Permanent(nil))
+
+	calls = 0
+	errFatal := errors.New("fatal")
+	err = Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errRetry
+		}
+		return errFatal
+	}, WithBackoff(ConstantBackoff(0)), WithRetryable(func(err error) bool {
+		return !errors.Is(err, errFatal)
+	}))
+	assert.Equal(t, errFatal, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryWithFakeClock(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	var attempts []time.Duration
+	done := make(chan error)
+	go func() {
+		done <- Retry(context.Background(), func(ctx context.Context) error {
+			attempts = append(attempts, clock.Since(fakeEpoch))
+			return errRetry
+		}, WithRetryClock(clock), WithMaxAttempts(0),
+			WithBackoff(ExponentialBackoff(time.Second, time.Minute, 2)),
+			WithMaxElapsedTime(10*time.Second))
+	}()
+
+	// waits 1s, 2s and 4s, the next 8s would exceed the elapsed time.
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		clock.BlockUntil(1)
+		clock.Advance(delay)
+	}
+	assert.Equal(t, errRetry, <-done)
+	assert.Equal(t, []time.Duration{0, time.Second, 3 * time.Second, 7 * time.Second}, attempts)
+}
+
+func TestRetryContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Retry(ctx, func(ctx context.Context) error {
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	clock := NewFakeClock(fakeEpoch)
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Retry(ctx, func(ctx context.Context) error {
+			return errRetry
+		}, WithRetryClock(clock), WithBackoff(ConstantBackoff(time.Hour)))
+	}()
+	clock.BlockUntil(1)
+	cancel()
+	assert.Equal(t, errRetry, <-done)
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	var calls int
+	done := make(chan error)
+	go func() {
+		done <- Retry(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls == 2 {
+				return nil
+			}
+			<-ctx.Done()
+			// the same error as the real clock.
+			assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+			return ctx.Err()
+		}, WithRetryClock(clock), WithAttemptTimeout(time.Second), WithBackoff(ConstantBackoff(0)))
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Nil(t, <-done)
+	assert.Equal(t, 2, calls)
+
+	// the real clock.
+	err := Retry(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithAttemptTimeout(time.Millisecond), WithMaxAttempts(1))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRetrySpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(sdk.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "retry")
+
+	err := Retry(ctx, func(ctx context.Context) error {
+		return errRetry
+	}, WithBackoff(ConstantBackoff(time.Millisecond)), WithMaxAttempts(2))
+	assert.Equal(t, errRetry, err)
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	assert.Len(t, events, 2)
+	for i, event := range events {
+		assert.Equal(t, retryEventName, event.Name)
+		attrs := make(map[string]interface{})
+		for _, kv := range event.Attributes {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		assert.Equal(t, int64(i+1), attrs["retry.attempt"])
+		assert.Equal(t, "retry", attrs["retry.error"])
+		assert.Equal(t, i == 0, attrs["retry.will_retry"])
+	}
+}
+
+func TestRetrySpanEventsSuccess(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(sdk.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "retry")
+
+	var calls int
+	err := Retry(ctx, func(ctx context.Context) error {
+		calls++
+		if calls == 2 {
+			return nil
+		}
+		return errRetry
+	}, WithBackoff(ConstantBackoff(0)))
+	assert.Nil(t, err)
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	assert.Len(t, events, 2)
+	last := make(map[string]interface{})
+	for _, kv := range events[1].Attributes {
+		last[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, int64(2), last["retry.attempt"])
+	assert.Equal(t, false, last["retry.will_retry"])
+	assert.NotContains(t, last, "retry.error")
+}
+
+func TestWithClockTimeout(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	ctx, cancel := withClockTimeout(context.Background(), clock, time.Second)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-child.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, context.DeadlineExceeded, child.Err())
+
+	ctx, cancel = withClockTimeout(context.Background(), clock, time.Second)
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
@@ -0,0 +1,163 @@
+package timex
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type (
+	// A Bucket aggregates the values added to a RollingWindow during an interval.
+	Bucket struct {
+		Count int64
+		Sum   float64
+		Min   float64
+		Max   float64
+	}
+
+	// A RollingWindow aggregates the values of the last size intervals, the
+	// oldest bucket is dropped as the time goes.
+	RollingWindow struct {
+		mu      sync.Mutex
+		ring    *ring
+		buckets []Bucket
+	}
+
+	// ring tracks the bucket of the current interval, it calls reset on the
+	// buckets whose interval elapsed.
+	ring struct {
+		clock    Clock
+		size     int
+		interval time.Duration
+		offset   int
+		last     time.Time
+		reset    func(i int)
+	}
+)
+
+// NewRollingWindow returns a RollingWindow of size buckets of interval.
+func NewRollingWindow(size int, interval time.Duration) *RollingWindow {
+	return NewRollingWindowWithClock(NewRealClock(), size, interval)
+}
+
+// NewRollingWindowWithClock returns a RollingWindow driven by clock.
+func NewRollingWindowWithClock(clock Clock, size int, interval time.Duration) *RollingWindow {
+	rw := &RollingWindow{
+		buckets: make([]Bucket, size),
+	}
+	rw.ring = newRing(clock, size, interval, func(i int) {
+		rw.buckets[i] = Bucket{}
+	})
+
+	return rw
+}
+
+// Add adds v to the bucket of the current interval.
+func (rw *RollingWindow) Add(v float64) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.buckets[rw.ring.current()].add(v)
+}
+
+// Reduce calls fn with the buckets of the window, oldest first.
+func (rw *RollingWindow) Reduce(fn func(b *Bucket)) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.ring.each(func(i int) {
+		fn(&rw.buckets[i])
+	})
+}
+
+// Stats returns the aggregate of the buckets of the window.
+func (rw *RollingWindow) Stats() Bucket {
+	var stats Bucket
+	rw.Reduce(func(b *Bucket) {
+		stats.merge(b)
+	})
+
+	return stats
+}
+
+// Mean returns the mean of the values, zero if there is none.
+func (b *Bucket) Mean() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+
+	return b.Sum / float64(b.Count)
+}
+
+func (b *Bucket) add(v float64) {
+	if b.Count == 0 {
+		b.Min, b.Max = v, v
+	} else {
+		b.Min = math.Min(b.Min, v)
+		b.Max = math.Max(b.Max, v)
+	}
+	b.Count++
+	b.Sum += v
+}
+
+func (b *Bucket) merge(o *Bucket) {
+	if o.Count == 0 {
+		return
+	}
+
+	if b.Count == 0 {
+		b.Min, b.Max = o.Min, o.Max
+	} else {
+		b.Min = math.Min(b.Min, o.Min)
+		b.Max = math.Max(b.Max, o.Max)
+	}
+	b.Count += o.Count
+	b.Sum += o.Sum
+}
+
+func newRing(clock Clock, size int, interval time.Duration, reset func(i int)) *ring {
+	if size <= 0 {
+		panic("non-positive size for a rolling window")
+	}
+	if interval <= 0 {
+		panic("non-positive interval for a rolling window")
+	}
+
+	return &ring{
+		clock:    clock,
+		size:     size,
+		interval: interval,
+		last:     clock.Now(),
+		reset:    reset,
+	}
+}
+
+// current returns the bucket of the current interval.
+func (r *ring) current() int {
+	r.rotate()
+	return r.offset
+}
+
+// each calls fn with the buckets of the window, oldest first.
+func (r *ring) each(fn func(i int)) {
+	r.rotate()
+	for i := 1; i <= r.size; i++ {
+		fn((r.offset + i) % r.size)
+	}
+}
+
+// rotate resets the buckets of the intervals elapsed since the last rotation.
+func (r *ring) rotate() {
+	elapsed := r.clock.Since(r.last)
+	if elapsed < r.interval {
+		return
+	}
+
+	spans := int(elapsed / r.interval)
+	for i := 1; i <= spans && i <= r.size; i++ {
+		r.reset((r.offset + i) % r.size)
+	}
+	r.offset = (r.offset + spans) % r.size
+	// keep the intervals aligned to the first one.
+	r.last = r.last.Add(time.Duration(spans) * r.interval)
+}
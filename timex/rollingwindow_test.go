@@ -0,0 +1,65 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingWindow(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	rw := NewRollingWindowWithClock(clock, 3, time.Second)
+	assert.Equal(t, Bucket{}, rw.Stats())
+
+	rw.Add(1)
+	rw.Add(5)
+	clock.Advance(time.Second)
+	rw.Add(-2)
+	clock.Advance(time.Second)
+	rw.Add(10)
+
+	stats := rw.Stats()
+	assert.Equal(t, Bucket{Count: 4, Sum: 14, Min: -2, Max: 10}, stats)
+	assert.Equal(t, 3.5, stats.Mean())
+
+	var counts []int64
+	rw.Reduce(func(b *Bucket) {
+		counts = append(counts, b.Count)
+	})
+	assert.Equal(t, []int64{2, 1, 1}, counts)
+
+	// the first bucket expires.
+	clock.Advance(time.Second)
+	assert.Equal(t, Bucket{Count: 2, Sum: 8, Min: -2, Max: 10}, rw.Stats())
+
+	// all the buckets expire.
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, Bucket{}, rw.Stats())
+}
+
+func TestRollingWindowAligned(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	rw := NewRollingWindowWithClock(clock, 2, time.Second)
+
+	clock.Advance(1500 * time.Millisecond)
+	rw.Add(1)
+	// still the same interval, which started at 1s.
+	clock.Advance(400 * time.Millisecond)
+	rw.Add(2)
+
+	var counts []int64
+	rw.Reduce(func(b *Bucket) {
+		counts = append(counts, b.Count)
+	})
+	assert.Equal(t, []int64{0, 2}, counts)
+}
+
+func TestRollingWindowPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		NewRollingWindow(0, time.Second)
+	})
+	assert.Panics(t, func() {
+		NewRollingWindow(1, 0)
+	})
+}
@@ -0,0 +1,435 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// OverlapSkip skips a run while the previous one is running.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue delays a run until the previous one finishes.
+	OverlapQueue
+	// OverlapAllow runs concurrently with the previous runs.
+	OverlapAllow
+)
+
+// schedulerTracerName is the instrumentation scope of the spans of the runs.
+const schedulerTracerName = "github.com/nextmicro/gokit/timex"
+
+// ErrSchedulerStopped is returned when adding a job to a stopped Scheduler.
+var ErrSchedulerStopped = errors.New("scheduler is stopped")
+
+type (
+	// OverlapPolicy decides what to do when a job is due while still running.
+	OverlapPolicy int
+
+	// JobID identifies a job of a Scheduler.
+	JobID int
+
+	// A Job is the function run by a Scheduler.
+	Job func(ctx context.Context) error
+
+	// An Entry describes a job of a Scheduler.
+	Entry struct {
+		ID   JobID
+		Name string
+		// Prev is the last time the job was due, zero if never.
+		Prev time.Time
+		// Next is the next time the job is due, jitter included.
+		Next time.Time
+	}
+
+	// SchedulerOption customizes a Scheduler.
+	SchedulerOption func(*Scheduler)
+
+	// JobOption customizes a job.
+	JobOption func(*entry)
+
+	// A Scheduler runs jobs on cron schedules, see ParseCron.
+	//
+	// Each run starts an internal span, named after the job, which records
+	// the error or the panic of the job.
+	Scheduler struct {
+		clock    Clock
+		location *time.Location
+		tracer   trace.Tracer
+
+		mu      sync.Mutex
+		entries map[JobID]*entry
+		nextID  JobID
+		started bool
+		stopped bool
+		wake    chan struct{}
+		quit    chan struct{}
+		done    chan struct{}
+
+		jobs      sync.WaitGroup
+		ctx       context.Context
+		cancelJob context.CancelFunc
+	}
+
+	entry struct {
+		id       JobID
+		name     string
+		schedule Schedule
+		job      Job
+		overlap  OverlapPolicy
+		jitter   time.Duration
+
+		// nominal is the next time of the schedule, next adds the jitter.
+		nominal time.Time
+		next    time.Time
+		prev    time.Time
+		running int
+		pending int
+	}
+)
+
+// WithSchedulerClock sets the Clock of the Scheduler.
+func WithSchedulerClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithLocation sets the time zone of the schedules without CRON_TZ,
+// defaults to time.Local.
+func WithLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithJobName sets the name of the job, which names its spans, defaults to
+// the spec.
+func WithJobName(name string) JobOption {
+	return func(e *entry) {
+		e.name = name
+	}
+}
+
+// WithOverlap sets the OverlapPolicy of the job, defaults to OverlapSkip.
+func WithOverlap(policy OverlapPolicy) JobOption {
+	return func(e *entry) {
+		e.overlap = policy
+	}
+}
+
+// WithJitter delays each run by a random duration in [0, jitter), it
+// spreads the jobs of many instances due at the same time.
+func WithJitter(jitter time.Duration) JobOption {
+	return func(e *entry) {
+		e.jitter = jitter
+	}
+}
+
+// NewScheduler returns a Scheduler, which runs the jobs once started.
+//
+// The spans of the runs are started with otel.Tracer of the global
+// TracerProvider, under the instrumentation scope
+// "github.com/nextmicro/gokit/timex", with the kind SpanKindInternal. They
+// are not started by the NewTracer of the gokit trace module, so that timex
+// does not depend on it.
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		clock:    NewRealClock(),
+		location: time.Local,
+		tracer:   otel.Tracer(schedulerTracerName),
+		entries:  make(map[JobID]*entry),
+		wake:     make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancelJob = context.WithCancel(context.Background())
+
+	return s
+}
+
+// AddJob schedules job on spec, see ParseCron for the syntax.
+func (s *Scheduler) AddJob(spec string, job Job, opts ...JobOption) (JobID, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Schedule(schedule, job, append([]JobOption{WithJobName(spec)}, opts...)...)
+}
+
+// Schedule schedules job on schedule.
+func (s *Scheduler) Schedule(schedule Schedule, job Job, opts ...JobOption) (JobID, error) {
+	e := &entry{
+		schedule: schedule,
+		job:      job,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return 0, ErrSchedulerStopped
+	}
+
+	s.nextID++
+	e.id = s.nextID
+	if e.name == "" {
+		e.name = fmt.Sprintf("job-%d", e.id)
+	}
+	s.scheduleLocked(e, s.clock.Now())
+	s.entries[e.id] = e
+	s.wakeUp()
+
+	return e.id, nil
+}
+
+// Remove unschedules the job of id, its running runs are not stopped.
+func (s *Scheduler) Remove(id JobID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	s.wakeUp()
+}
+
+// Entries returns the jobs, ordered by their next run.
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, Entry{ID: e.id, Name: e.name, Prev: e.prev, Next: e.next})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Next.Equal(entries[j].Next) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].Next.Before(entries[j].Next)
+	})
+
+	return entries
+}
+
+// Start starts running the jobs in the background, it does nothing if
+// already started or stopped.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	go s.run()
+}
+
+// Stop stops scheduling the jobs, and waits for the running ones, their
+// queued runs are dropped. If ctx is done first, the contexts of the running
+// jobs are canceled, and the error of ctx is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.quit)
+	}
+	started := s.started
+	s.mu.Unlock()
+
+	if started {
+		<-s.done
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(jobsDone)
+	}()
+
+	select {
+	case <-jobsDone:
+		s.cancelJob()
+		return nil
+	case <-ctx.Done():
+		s.cancelJob()
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	for {
+		var timer Timer
+		var fire <-chan time.Time
+		if next, ok := s.nextRun(); ok {
+			timer = s.clock.NewTimer(next.Sub(s.clock.Now()))
+			fire = timer.Chan()
+		}
+
+		select {
+		case now := <-fire:
+			s.runDue(now)
+		case <-s.wake:
+		case <-s.quit:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+	}
+}
+
+// nextRun returns the earliest next run of the jobs.
+func (s *Scheduler) nextRun() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || e.next.Before(next) {
+			next = e.next
+		}
+	}
+
+	return next, !next.IsZero()
+}
+
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*entry
+	for _, e := range s.entries {
+		if !e.next.IsZero() && !e.next.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].id < due[j].id
+	})
+
+	for _, e := range due {
+		e.prev = e.next
+		s.scheduleLocked(e, now)
+		s.dispatchLocked(e)
+	}
+}
+
+// scheduleLocked sets the next run of e after now.
+func (s *Scheduler) scheduleLocked(e *entry, now time.Time) {
+	after := now
+	// the jitter must not run the job twice for the same activation.
+	if e.nominal.After(after) {
+		after = e.nominal
+	}
+	e.nominal = e.schedule.Next(after.In(s.location))
+	e.next = e.nominal
+	if !e.next.IsZero() && e.jitter > 0 {
+		e.next = e.next.Add(time.Duration(rand.Int63n(int64(e.jitter))))
+	}
+}
+
+func (s *Scheduler) dispatchLocked(e *entry) {
+	if e.running > 0 {
+		switch e.overlap {
+		case OverlapSkip:
+			return
+		case OverlapQueue:
+			e.pending++
+			return
+		}
+	}
+
+	e.running++
+	s.jobs.Add(1)
+	go s.runJob(e)
+}
+
+// runJob runs e, then its queued runs.
+func (s *Scheduler) runJob(e *entry) {
+	defer s.jobs.Done()
+
+	for {
+		s.execute(e)
+
+		s.mu.Lock()
+		if e.pending == 0 || s.stopped {
+			e.pending = 0
+			e.running--
+			s.mu.Unlock()
+			return
+		}
+		e.pending--
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) execute(e *entry) {
+	ctx, span := s.tracer.Start(s.ctx, e.name, trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("cron.job", e.name),
+			attribute.Int("cron.job_id", int(e.id)),
+		))
+	defer span.End()
+
+	defer func() {
+		if p := recover(); p != nil {
+			// a panic must not stop the scheduler.
+			recordJobError(span, fmt.Errorf("panic: %v", p))
+		}
+	}()
+
+	if err := e.job(ctx); err != nil {
+		recordJobError(span, err)
+	}
+}
+
+func recordJobError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// wakeUp makes the run loop look at the entries again.
+func (s *Scheduler) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapSkip:
+		return "skip"
+	case OverlapQueue:
+		return "queue"
+	case OverlapAllow:
+		return "allow"
+	default:
+		return fmt.Sprintf("OverlapPolicy(%d)", int(p))
+	}
+}
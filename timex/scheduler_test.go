@@ -0,0 +1,228 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestScheduler() (*Scheduler, FakeClock) {
+	clock := NewFakeClock(fakeEpoch)
+	return NewScheduler(WithSchedulerClock(clock), WithLocation(time.UTC)), clock
+}
+
+func TestScheduler(t *testing.T) {
+	s, clock := newTestScheduler()
+	runs := make(chan time.Time, 10)
+	id, err := s.AddJob("@every 1m", func(ctx context.Context) error {
+		runs <- clock.Now()
+		return nil
+	})
+	assert.Nil(t, err)
+	_, err = s.AddJob("0 0 * * *", func(ctx context.Context) error {
+		return nil
+	}, WithJobName("daily"))
+	assert.Nil(t, err)
+
+	entries := s.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, id, entries[0].ID)
+	assert.Equal(t, "@every 1m", entries[0].Name)
+	assert.Equal(t, fakeEpoch.Add(time.Minute), entries[0].Next)
+	assert.Equal(t, "daily", entries[1].Name)
+	assert.Equal(t, fakeEpoch.AddDate(0, 0, 1), entries[1].Next)
+
+	s.Start()
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		assert.Equal(t, fakeEpoch.Add(time.Duration(i)*time.Minute), <-runs)
+	}
+
+	s.Remove(id)
+	assert.Len(t, s.Entries(), 1)
+	assert.Nil(t, s.Stop(context.Background()))
+	_, err = s.AddJob("@daily", func(ctx context.Context) error {
+		return nil
+	})
+	assert.Equal(t, ErrSchedulerStopped, err)
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	s, _ := newTestScheduler()
+	for i := 0; i < 10; i++ {
+		_, err := s.AddJob("@hourly", func(ctx context.Context) error {
+			return nil
+		}, WithJitter(time.Minute))
+		assert.Nil(t, err)
+	}
+
+	for _, entry := range s.Entries() {
+		delay := entry.Next.Sub(fakeEpoch.Add(time.Hour))
+		assert.True(t, delay >= 0 && delay < time.Minute, delay)
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		policy OverlapPolicy
+		runs   int32
+	}{
+		{OverlapSkip, 1},
+		{OverlapQueue, 3},
+		{OverlapAllow, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			s, clock := newTestScheduler()
+			release := make(chan struct{})
+			started := make(chan struct{}, 10)
+			var runs, running, maxRunning int32
+			_, err := s.AddJob("@every 1s", func(ctx context.Context) error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				if n > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, n)
+				}
+				atomic.AddInt32(&runs, 1)
+				started <- struct{}{}
+				<-release
+				return nil
+			}, WithOverlap(test.policy))
+			assert.Nil(t, err)
+			s.Start()
+
+			for i := 0; i < 3; i++ {
+				clock.BlockUntil(1)
+				clock.Advance(time.Second)
+			}
+			clock.BlockUntil(1)
+			<-started
+			if test.policy == OverlapAllow {
+				<-started
+				<-started
+				assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+			}
+			close(release)
+			if test.policy == OverlapQueue {
+				// the queued runs, which are dropped once stopped.
+				<-started
+				<-started
+			}
+
+			assert.Nil(t, s.Stop(context.Background()))
+			assert.Equal(t, test.runs, atomic.LoadInt32(&runs))
+			if test.policy != OverlapAllow {
+				assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+			}
+		})
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	s, clock := newTestScheduler()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var canceled int32
+	_, err := s.AddJob("@every 1s", func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			atomic.StoreInt32(&canceled, 1)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	s.Start()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-started
+
+	// waits for the running job.
+	stopped := make(chan error)
+	go func() {
+		stopped <- s.Stop(context.Background())
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("stopped before the job finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	assert.Nil(t, <-stopped)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&canceled))
+
+	// a stopped scheduler does not start.
+	s.Start()
+	assert.Nil(t, s.Stop(context.Background()))
+}
+
+func TestSchedulerStopTimeout(t *testing.T) {
+	s, clock := newTestScheduler()
+	started := make(chan struct{})
+	done := make(chan struct{})
+	_, err := s.AddJob("@every 1s", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(done)
+		return ctx.Err()
+	})
+	assert.Nil(t, err)
+	s.Start()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Stop(ctx))
+	// the job is canceled.
+	<-done
+}
+
+func TestSchedulerSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(sdk.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prev)
+
+	s, clock := newTestScheduler()
+	errJob := errors.New("job")
+	_, err := s.AddJob("@every 1s", func(ctx context.Context) error {
+		return errJob
+	}, WithJobName("failing"))
+	assert.Nil(t, err)
+	_, err = s.AddJob("@every 1s", func(ctx context.Context) error {
+		panic("boom")
+	}, WithJobName("panicking"))
+	assert.Nil(t, err)
+	s.Start()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	assert.Nil(t, s.Stop(context.Background()))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	names := map[string]bool{}
+	for _, span := range spans {
+		names[span.Name()] = true
+		assert.Equal(t, schedulerTracerName, span.InstrumentationScope().Name)
+		assert.Equal(t, trace.SpanKindInternal, span.SpanKind())
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Len(t, span.Events(), 1)
+	}
+	assert.Equal(t, map[string]bool{"failing": true, "panicking": true}, names)
+}
@@ -0,0 +1,132 @@
+package timex
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// scheduleTicker is a Ticker whose next tick is computed by next, it is
+// driven by a Timer of its Clock so that it works with a FakeClock.
+type scheduleTicker struct {
+	c     chan time.Time
+	timer Timer
+	reset chan time.Duration
+	// resetDone acknowledges a reset once the timer is re-armed, so that the
+	// clock cannot advance in between.
+	resetDone chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+	// next returns the delay from now to the next tick, with the period d.
+	next func(now time.Time, d time.Duration) time.Duration
+}
+
+// NewJitterTicker returns a Ticker whose period is randomized within
+// d ± d*jitter, jitter must be in [0, 1). It spreads the ticks of the replicas
+// started at the same time.
+func NewJitterTicker(d time.Duration, jitter float64) Ticker {
+	return NewJitterTickerWithClock(NewRealClock(), d, jitter)
+}
+
+// NewJitterTickerWithClock returns a jittered Ticker driven by clock, see NewJitterTicker.
+func NewJitterTickerWithClock(clock Clock, d time.Duration, jitter float64) Ticker {
+	if jitter < 0 || jitter >= 1 {
+		panic("jitter of NewJitterTicker must be in [0, 1)")
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return newScheduleTicker(clock, d, func(now time.Time, d time.Duration) time.Duration {
+		delta := time.Duration((r.Float64()*2 - 1) * jitter * float64(d))
+		return d + delta
+	})
+}
+
+// NewAlignedTicker returns a Ticker which ticks on the wall-clock boundaries
+// of d, for example every minute at :00 with time.Minute. The boundaries are
+// the multiples of d since the zero time, as computed by time.Time.Truncate.
+func NewAlignedTicker(d time.Duration) Ticker {
+	return NewAlignedTickerWithClock(NewRealClock(), d)
+}
+
+// NewAlignedTickerWithClock returns an aligned Ticker driven by clock, see NewAlignedTicker.
+func NewAlignedTickerWithClock(clock Clock, d time.Duration) Ticker {
+	return newScheduleTicker(clock, d, func(now time.Time, d time.Duration) time.Duration {
+		return now.Truncate(d).Add(d).Sub(now)
+	})
+}
+
+func newScheduleTicker(clock Clock, d time.Duration,
+	next func(now time.Time, d time.Duration) time.Duration) *scheduleTicker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	t := &scheduleTicker{
+		c:         make(chan time.Time, 1),
+		reset:     make(chan time.Duration),
+		resetDone: make(chan struct{}),
+		done:      make(chan struct{}),
+		next:      next,
+	}
+	t.timer = clock.NewTimer(next(clock.Now(), d))
+	go t.run(clock, d)
+
+	return t
+}
+
+func (t *scheduleTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (t *scheduleTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	select {
+	case t.reset <- d:
+	case <-t.done:
+		return
+	}
+	select {
+	case <-t.resetDone:
+	case <-t.done:
+	}
+}
+
+func (t *scheduleTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+func (t *scheduleTicker) run(clock Clock, d time.Duration) {
+	defer t.timer.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case d = <-t.reset:
+			if !t.timer.Stop() {
+				select {
+				case <-t.timer.Chan():
+				default:
+				}
+			}
+			t.timer.Reset(t.next(clock.Now(), d))
+			select {
+			case t.resetDone <- struct{}{}:
+			case <-t.done:
+				return
+			}
+		case now := <-t.timer.Chan():
+			// drop the tick if the receiver is slow, like time.Ticker.
+			select {
+			case t.c <- now:
+			default:
+			}
+			t.timer.Reset(t.next(clock.Now(), d))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitterTicker(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	ticker := NewJitterTickerWithClock(clock, time.Minute, 0.2)
+	defer ticker.Stop()
+
+	for i := 0; i < 20; i++ {
+		clock.BlockUntil(1)
+		last := clock.Now()
+		clock.Advance(72 * time.Second)
+		period := (<-ticker.Chan()).Sub(last)
+		assert.True(t, period >= 48*time.Second && period <= 72*time.Second, period)
+	}
+
+	assert.Panics(t, func() {
+		NewJitterTicker(time.Second, 1)
+	})
+	assert.Panics(t, func() {
+		NewJitterTicker(0, 0.1)
+	})
+}
+
+func TestAlignedTicker(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch.Add(17*time.Second + 300*time.Millisecond))
+	ticker := NewAlignedTickerWithClock(clock, time.Minute)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		assert.Equal(t, fakeEpoch.Add(time.Duration(i)*time.Minute), <-ticker.Chan())
+	}
+}
+
+func TestScheduleTickerReset(t *testing.T) {
+	clock := NewFakeClock(fakeEpoch)
+	ticker := NewAlignedTickerWithClock(clock, time.Minute)
+
+	clock.BlockUntil(1)
+	ticker.Reset(time.Hour)
+	clock.Advance(30 * time.Minute)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("ticker fired before the new period")
+	default:
+	}
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, fakeEpoch.Add(time.Hour), <-ticker.Chan())
+
+	ticker.Stop()
+	ticker.Stop()
+	ticker.Reset(time.Second)
+	assert.Panics(t, func() {
+		ticker.Reset(0)
+	})
+}
+
+func TestRealScheduleTicker(t *testing.T) {
+	ticker := NewJitterTicker(10*time.Millisecond, 0.5)
+	<-ticker.Chan()
+	ticker.Reset(time.Millisecond)
+	<-ticker.Chan()
+	ticker.Stop()
+
+	ticker = NewAlignedTicker(10 * time.Millisecond)
+	<-ticker.Chan()
+	ticker.Stop()
+}
@@ -6,9 +6,12 @@ import (
 )
 
 type (
-	// Ticker interface wraps the Chan and Stop methods.
+	// Ticker interface wraps the Chan, Reset and Stop methods.
 	Ticker interface {
 		Chan() <-chan time.Time
+		// Reset stops the ticker and resets its period to d, the next tick
+		// arrives after the new period elapses.
+		Reset(d time.Duration)
 		Stop()
 	}
 
@@ -37,6 +40,11 @@ func NewTicker(d time.Duration) Ticker {
 	}
 }
 
+// NewTickerWithClock returns a Ticker driven by clock.
+func NewTickerWithClock(clock Clock, d time.Duration) Ticker {
+	return clock.NewTicker(d)
+}
+
 func (rt *realTicker) Chan() <-chan time.Time {
 	return rt.C
 }
@@ -57,6 +65,19 @@ func (ft *fakeTicker) Done() {
 	ft.done <- struct{}{}
 }
 
+// Reset drops the pending tick, the ticks of a FakeTicker are sent by Tick
+// whatever the period.
+func (ft *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	select {
+	case <-ft.c:
+	default:
+	}
+}
+
 func (ft *fakeTicker) Stop() {
 	close(ft.c)
 }
@@ -48,3 +48,39 @@ func TestFakeTickerTimeout(t *testing.T) {
 
 	assert.NotNil(t, ticker.Wait(time.Millisecond))
 }
+
+func TestTickerReset(t *testing.T) {
+	ticker := NewTicker(time.Hour)
+	defer ticker.Stop()
+	ticker.Reset(time.Millisecond)
+	<-ticker.Chan()
+
+	clock := NewFakeClock(fakeEpoch)
+	ticker = NewTickerWithClock(clock, time.Second)
+	ticker.Reset(time.Minute)
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("ticker fired before the new period")
+	default:
+	}
+	clock.Advance(time.Minute)
+	assert.Equal(t, fakeEpoch.Add(time.Minute), <-ticker.Chan())
+	clock.Advance(time.Minute)
+	assert.Equal(t, fakeEpoch.Add(2*time.Minute), <-ticker.Chan())
+
+	// the pending tick of a FakeTicker is dropped.
+	ft := NewFakeTicker()
+	ft.Tick()
+	ft.Reset(time.Second)
+	select {
+	case <-ft.Chan():
+		t.Fatal("pending tick kept after Reset")
+	default:
+	}
+	ft.Tick()
+	<-ft.Chan()
+	assert.Panics(t, func() {
+		ft.Reset(0)
+	})
+}
@@ -7,29 +7,36 @@ import (
 
 // A ElapsedTimer is a timer to track the elapsed time.
 type ElapsedTimer struct {
-	start time.Duration
+	clock Clock
+	start time.Time
 }
 
 // NewElapsedTimer returns a ElapsedTimer.
 func NewElapsedTimer() *ElapsedTimer {
+	return NewElapsedTimerWithClock(NewRealClock())
+}
+
+// NewElapsedTimerWithClock returns a ElapsedTimer reading the time from clock.
+func NewElapsedTimerWithClock(clock Clock) *ElapsedTimer {
 	return &ElapsedTimer{
-		start: Now(),
+		clock: clock,
+		start: clock.Now(),
 	}
 }
 
 // Duration returns the elapsed time.
 func (et *ElapsedTimer) Duration() time.Duration {
-	return Since(et.start)
+	return et.clock.Since(et.start)
 }
 
 // Elapsed returns the string representation of elapsed time.
 func (et *ElapsedTimer) Elapsed() string {
-	return Since(et.start).String()
+	return et.Duration().String()
 }
 
 // ElapsedMs returns the elapsed time of string on milliseconds.
 func (et *ElapsedTimer) ElapsedMs() string {
-	return fmt.Sprintf("%.1fms", float32(Since(et.start))/float32(time.Millisecond))
+	return fmt.Sprintf("%.1fms", float32(et.Duration())/float32(time.Millisecond))
 }
 
 // CurrentMicros returns the current microseconds.
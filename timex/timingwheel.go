@@ -0,0 +1,278 @@
+package timex
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// timingWheelLevels is the number of levels of a TimingWheel, the timers
+// beyond the last level wait there for more revolutions.
+const timingWheelLevels = 4
+
+var (
+	// ErrTimingWheelArgument is returned by NewTimingWheel on an invalid argument.
+	ErrTimingWheelArgument = errors.New("incorrect timing wheel argument")
+	// ErrTimingWheelClosed is returned on the operations of a stopped TimingWheel.
+	ErrTimingWheelClosed = errors.New("timing wheel is closed")
+)
+
+type (
+	// A TimingEntry is a timer expired by a TimingWheel.
+	TimingEntry struct {
+		Key   interface{}
+		Value interface{}
+	}
+
+	// Execute handles the timers expired at the same tick, it runs on the
+	// goroutine of the TimingWheel, so the next tick waits for it.
+	Execute func(entries []TimingEntry)
+
+	// A TimingWheel is a hierarchical timing wheel which tracks massive
+	// numbers of timers with O(1) set, move and remove, at the precision of
+	// its interval.
+	//
+	// Level 0 has numSlots slots of interval, and each upper level has numSlots
+	// slots of a whole revolution of the level below, the timers cascade down
+	// as their expiration comes closer.
+	TimingWheel struct {
+		interval time.Duration
+		numSlots uint64
+		execute  Execute
+		ticker   Ticker
+
+		mu      sync.Mutex
+		tick    uint64
+		levels  [timingWheelLevels][]*list.List
+		timers  map[interface{}]*list.Element
+		stopped bool
+
+		done     chan struct{}
+		stopOnce sync.Once
+		wg       sync.WaitGroup
+	}
+
+	timingWheelEntry struct {
+		key    interface{}
+		value  interface{}
+		expire uint64
+		slot   *list.List
+	}
+)
+
+// NewTimingWheel returns a TimingWheel ticking every interval with numSlots
+// slots per level, execute is called with the expired timers.
+func NewTimingWheel(interval time.Duration, numSlots int, execute Execute) (*TimingWheel, error) {
+	if interval <= 0 {
+		return nil, ErrTimingWheelArgument
+	}
+
+	return NewTimingWheelWithTicker(interval, numSlots, execute, NewTicker(interval))
+}
+
+// NewTimingWheelWithTicker returns a TimingWheel driven by ticker, every tick
+// advances the wheel by interval. It is used with NewFakeTicker for testing.
+func NewTimingWheelWithTicker(interval time.Duration, numSlots int, execute Execute,
+	ticker Ticker) (*TimingWheel, error) {
+	if interval <= 0 || numSlots <= 1 || execute == nil {
+		return nil, ErrTimingWheelArgument
+	}
+
+	tw := &TimingWheel{
+		interval: interval,
+		numSlots: uint64(numSlots),
+		execute:  execute,
+		ticker:   ticker,
+		timers:   make(map[interface{}]*list.Element),
+		done:     make(chan struct{}),
+	}
+	for i := range tw.levels {
+		tw.levels[i] = make([]*list.List, numSlots)
+		for j := range tw.levels[i] {
+			tw.levels[i][j] = list.New()
+		}
+	}
+
+	tw.wg.Add(1)
+	go tw.run()
+	return tw, nil
+}
+
+// SetTimer sets the timer of key to expire with value after delay, the
+// timer of an existing key is replaced.
+func (tw *TimingWheel) SetTimer(key, value interface{}, delay time.Duration) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.stopped {
+		return ErrTimingWheelClosed
+	}
+
+	if elem, ok := tw.timers[key]; ok {
+		tw.removeLocked(elem)
+	}
+	tw.timers[key] = tw.addLocked(&timingWheelEntry{
+		key:    key,
+		value:  value,
+		expire: tw.tick + tw.ticks(delay),
+	})
+	return nil
+}
+
+// MoveTimer moves the timer of key to expire after delay, it does nothing
+// if key has no timer.
+func (tw *TimingWheel) MoveTimer(key interface{}, delay time.Duration) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.stopped {
+		return ErrTimingWheelClosed
+	}
+
+	elem, ok := tw.timers[key]
+	if !ok {
+		return nil
+	}
+	entry := tw.removeLocked(elem)
+	entry.expire = tw.tick + tw.ticks(delay)
+	tw.timers[key] = tw.addLocked(entry)
+	return nil
+}
+
+// RemoveTimer removes the timer of key.
+func (tw *TimingWheel) RemoveTimer(key interface{}) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.stopped {
+		return ErrTimingWheelClosed
+	}
+
+	if elem, ok := tw.timers[key]; ok {
+		tw.removeLocked(elem)
+		delete(tw.timers, key)
+	}
+	return nil
+}
+
+// Len returns the number of pending timers.
+func (tw *TimingWheel) Len() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return len(tw.timers)
+}
+
+// Stop stops the TimingWheel, the pending timers are dropped.
+func (tw *TimingWheel) Stop() {
+	tw.stopOnce.Do(func() {
+		tw.mu.Lock()
+		tw.stopped = true
+		tw.mu.Unlock()
+
+		close(tw.done)
+		tw.wg.Wait()
+		tw.ticker.Stop()
+	})
+}
+
+func (tw *TimingWheel) run() {
+	defer tw.wg.Done()
+
+	for {
+		select {
+		case <-tw.done:
+			return
+		case _, ok := <-tw.ticker.Chan():
+			if !ok {
+				return
+			}
+			if entries := tw.onTick(); len(entries) > 0 {
+				tw.execute(entries)
+			}
+		}
+	}
+}
+
+// onTick advances the wheel by one tick and returns the expired timers.
+func (tw *TimingWheel) onTick() []TimingEntry {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.tick++
+	// cascade the upper levels first, their timers may expire on this tick.
+	span := tw.span(timingWheelLevels - 1)
+	for level := timingWheelLevels - 1; level > 0; level-- {
+		if tw.tick%span == 0 {
+			tw.cascadeLocked(level, (tw.tick/span)%tw.numSlots)
+		}
+		span /= tw.numSlots
+	}
+
+	slot := tw.levels[0][tw.tick%tw.numSlots]
+	if slot.Len() == 0 {
+		return nil
+	}
+
+	entries := make([]TimingEntry, 0, slot.Len())
+	for elem := slot.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*timingWheelEntry)
+		entries = append(entries, TimingEntry{Key: entry.key, Value: entry.value})
+		delete(tw.timers, entry.key)
+	}
+	slot.Init()
+
+	return entries
+}
+
+func (tw *TimingWheel) cascadeLocked(level int, index uint64) {
+	slot := tw.levels[level][index]
+	if slot.Len() == 0 {
+		return
+	}
+
+	// swap the slot first, the timers beyond the last level come back to it.
+	tw.levels[level][index] = list.New()
+	for elem := slot.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*timingWheelEntry)
+		tw.timers[entry.key] = tw.addLocked(entry)
+	}
+}
+
+// addLocked puts entry in the lowest level whose revolution covers its expiration.
+func (tw *TimingWheel) addLocked(entry *timingWheelEntry) *list.Element {
+	delta := entry.expire - tw.tick
+	level, span := 0, uint64(1)
+	for level < timingWheelLevels-1 && delta >= span*tw.numSlots {
+		level++
+		span *= tw.numSlots
+	}
+
+	entry.slot = tw.levels[level][(entry.expire/span)%tw.numSlots]
+	return entry.slot.PushBack(entry)
+}
+
+func (tw *TimingWheel) removeLocked(elem *list.Element) *timingWheelEntry {
+	entry := elem.Value.(*timingWheelEntry)
+	entry.slot.Remove(elem)
+	return entry
+}
+
+// span returns the ticks of a slot of level.
+func (tw *TimingWheel) span(level int) uint64 {
+	span := uint64(1)
+	for i := 0; i < level; i++ {
+		span *= tw.numSlots
+	}
+
+	return span
+}
+
+// ticks returns the ticks to wait for delay, at least one.
+func (tw *TimingWheel) ticks(delay time.Duration) uint64 {
+	if delay <= tw.interval {
+		return 1
+	}
+
+	return uint64((delay + tw.interval - 1) / tw.interval)
+}
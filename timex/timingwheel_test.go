@@ -0,0 +1,190 @@
+package timex
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWheelInterval = time.Millisecond
+
+func newTestTimingWheel(t *testing.T, numSlots int) (*TimingWheel, *[]TimingEntry) {
+	var expired []TimingEntry
+	tw, err := NewTimingWheelWithTicker(testWheelInterval, numSlots, func(entries []TimingEntry) {
+		expired = append(expired, entries...)
+	}, NewFakeTicker())
+	assert.Nil(t, err)
+	t.Cleanup(tw.Stop)
+
+	return tw, &expired
+}
+
+// advance drives the wheel synchronously, so that the test needs no ticker.
+func advance(tw *TimingWheel, ticks int) {
+	for i := 0; i < ticks; i++ {
+		if entries := tw.onTick(); len(entries) > 0 {
+			tw.execute(entries)
+		}
+	}
+}
+
+func TestNewTimingWheel(t *testing.T) {
+	execute := func([]TimingEntry) {}
+	_, err := NewTimingWheel(0, 10, execute)
+	assert.Equal(t, ErrTimingWheelArgument, err)
+	_, err = NewTimingWheel(time.Second, 1, execute)
+	assert.Equal(t, ErrTimingWheelArgument, err)
+	_, err = NewTimingWheel(time.Second, 10, nil)
+	assert.Equal(t, ErrTimingWheelArgument, err)
+
+	tw, err := NewTimingWheel(time.Second, 10, execute)
+	assert.Nil(t, err)
+	tw.Stop()
+	tw.Stop()
+	assert.Equal(t, ErrTimingWheelClosed, tw.SetTimer("a", 1, time.Second))
+	assert.Equal(t, ErrTimingWheelClosed, tw.MoveTimer("a", time.Second))
+	assert.Equal(t, ErrTimingWheelClosed, tw.RemoveTimer("a"))
+}
+
+func TestTimingWheelSetTimer(t *testing.T) {
+	tw, expired := newTestTimingWheel(t, 8)
+	assert.Nil(t, tw.SetTimer("a", 1, 3*testWheelInterval))
+	assert.Nil(t, tw.SetTimer("b", 2, 3*testWheelInterval))
+	assert.Nil(t, tw.SetTimer("c", 3, 0))
+	assert.Equal(t, 3, tw.Len())
+
+	advance(tw, 1)
+	assert.Equal(t, []TimingEntry{{Key: "c", Value: 3}}, *expired)
+	advance(tw, 1)
+	assert.Len(t, *expired, 1)
+	advance(tw, 1)
+	// the timers expired at the same tick come in the same batch.
+	assert.Equal(t, []TimingEntry{{Key: "c", Value: 3}, {Key: "a", Value: 1}, {Key: "b", Value: 2}}, *expired)
+	assert.Equal(t, 0, tw.Len())
+
+	// replacing a timer.
+	assert.Nil(t, tw.SetTimer("a", 1, testWheelInterval))
+	assert.Nil(t, tw.SetTimer("a", 10, 2*testWheelInterval))
+	advance(tw, 1)
+	assert.Len(t, *expired, 3)
+	advance(tw, 1)
+	assert.Equal(t, TimingEntry{Key: "a", Value: 10}, (*expired)[3])
+}
+
+func TestTimingWheelMoveAndRemove(t *testing.T) {
+	tw, expired := newTestTimingWheel(t, 8)
+	assert.Nil(t, tw.SetTimer("a", 1, 2*testWheelInterval))
+	assert.Nil(t, tw.SetTimer("b", 2, 2*testWheelInterval))
+	assert.Nil(t, tw.MoveTimer("a", 100*testWheelInterval))
+	assert.Nil(t, tw.MoveTimer("missing", testWheelInterval))
+	assert.Nil(t, tw.RemoveTimer("b"))
+	assert.Nil(t, tw.RemoveTimer("missing"))
+	assert.Equal(t, 1, tw.Len())
+
+	advance(tw, 99)
+	assert.Empty(t, *expired)
+	advance(tw, 1)
+	assert.Equal(t, []TimingEntry{{Key: "a", Value: 1}}, *expired)
+}
+
+func TestTimingWheelLevels(t *testing.T) {
+	const numSlots = 4
+	tw, expired := newTestTimingWheel(t, numSlots)
+
+	// start from an unaligned tick.
+	advance(tw, 3)
+	// up to beyond the last level, which spans 4^4 ticks.
+	delays := []int{1, 3, 4, 5, 15, 16, 17, 63, 64, 65, 255, 256, 257, 1000, 1024, 3001}
+	for _, d := range delays {
+		assert.Nil(t, tw.SetTimer(d, d, time.Duration(d)*testWheelInterval))
+	}
+
+	var fired []int
+	for tick := 1; tick <= 3001; tick++ {
+		advance(tw, 1)
+		for _, entry := range *expired {
+			assert.Equal(t, tick, entry.Value, "timer %v fired at tick %d", entry.Key, tick)
+			fired = append(fired, entry.Value.(int))
+		}
+		*expired = (*expired)[:0]
+	}
+	assert.Equal(t, delays, fired)
+}
+
+func TestTimingWheelRandom(t *testing.T) {
+	tw, expired := newTestTimingWheel(t, 16)
+	advance(tw, 7)
+
+	r := rand.New(rand.NewSource(1))
+	want := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		d := r.Intn(20000) + 1
+		want[i] = d
+		assert.Nil(t, tw.SetTimer(i, d, time.Duration(d)*testWheelInterval))
+	}
+	for i := 0; i < 1000; i++ {
+		d := r.Intn(20000) + 1
+		want[i] = d
+		assert.Nil(t, tw.MoveTimer(i, time.Duration(d)*testWheelInterval))
+	}
+
+	for tick := 1; tick <= 20000; tick++ {
+		advance(tw, 1)
+		for _, entry := range *expired {
+			assert.Equal(t, want[entry.Key.(int)], tick)
+			delete(want, entry.Key.(int))
+		}
+		*expired = (*expired)[:0]
+	}
+	assert.Empty(t, want)
+	assert.Equal(t, 0, tw.Len())
+}
+
+func TestTimingWheelWithFakeTicker(t *testing.T) {
+	ticker := NewFakeTicker()
+	var mu sync.Mutex
+	var keys []string
+	tw, err := NewTimingWheelWithTicker(time.Second, 10, func(entries []TimingEntry) {
+		mu.Lock()
+		for _, entry := range entries {
+			keys = append(keys, entry.Key.(string))
+		}
+		mu.Unlock()
+		ticker.Done()
+	}, ticker)
+	assert.Nil(t, err)
+	defer tw.Stop()
+
+	assert.Nil(t, tw.SetTimer("conn-2", nil, 2*time.Second))
+	assert.Nil(t, tw.SetTimer("conn-1", nil, 2*time.Second))
+	ticker.Tick()
+	ticker.Tick()
+	assert.Nil(t, ticker.Wait(time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"conn-1", "conn-2"}, keys)
+}
+
+func BenchmarkTimingWheel(b *testing.B) {
+	tw, err := NewTimingWheelWithTicker(time.Second, 60, func([]TimingEntry) {}, NewFakeTicker())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tw.Stop()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = tw.SetTimer(i%100000, i, time.Duration(i%3600)*time.Second)
+		_ = tw.MoveTimer(i%100000, time.Duration(i%7200)*time.Second)
+		if i%10 == 0 {
+			_ = tw.RemoveTimer(i % 100000)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Command gokit-trace-replay reads span files written by the KindFile exporter
+// and re-exports them through any gokit trace exporter.
+//
+// Usage:
+//
+//	gokit-trace-replay -batcher otlpgrpc -endpoint collector:4317 -state traces.offset traces.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nextmicro/gokit/trace"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	batcher   = flag.String("batcher", trace.KindOtlpGrpc, "exporter kind to replay to")
+	endpoint  = flag.String("endpoint", "", "exporter endpoint")
+	headers   = flag.String("headers", "", "OTLP headers, key1=value1,key2=value2")
+	httpPath  = flag.String("path", "", "OTLP HTTP path")
+	rate      = flag.Int("rate", 0, "max spans per second, 0 means no limit")
+	batchSize = flag.Int("batch", 512, "max spans per export")
+	offset    = flag.Int64("offset", 0, "byte offset to resume the first file from")
+	state     = flag.String("state", "", "file storing the file and the offset to resume from")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Args()); err != nil {
+		log.Fatalf("[replay] error: %v", err)
+	}
+}
+
+func run(files []string) error {
+	opts := []trace.Option{
+		trace.WithBatcher(*batcher),
+		trace.WithEndpoint(*endpoint),
+		trace.WithOtlpHttpPath(*httpPath),
+	}
+	if len(*headers) > 0 {
+		h, err := parseHeaders(*headers)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, trace.WithOtlpHeaders(h))
+	}
+
+	exporter, err := trace.NewExporter(opts...)
+	if err != nil {
+		return err
+	}
+	defer exporter.Shutdown(context.Background())
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	return replayFiles(ctx, files, exporter, *state, *offset,
+		trace.WithReplayRate(*rate),
+		trace.WithReplayBatchSize(*batchSize),
+	)
+}
+
+// replayState is the content of the state file, the files before File are
+// completed, and File is resumed from Offset.
+type replayState struct {
+	File   string `json:"file"`
+	Offset int64  `json:"offset"`
+}
+
+// replayFiles replays files in order, resuming from the state stored in
+// statePath if any. A non-zero offset overrides the state, and applies to
+// the first file.
+func replayFiles(ctx context.Context, files []string, exporter sdk.SpanExporter, statePath string,
+	offset int64, opts ...trace.ReplayOption) error {
+	first, start := 0, offset
+	if len(statePath) > 0 && offset == 0 {
+		st, ok, err := readState(statePath)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if first = indexOfFile(files, st.File); first < 0 {
+				return fmt.Errorf("state %s resumes %s, which is not in the files to replay", statePath, st.File)
+			}
+			start = st.Offset
+		}
+	}
+
+	for i := first; i < len(files); i++ {
+		file := files[i]
+		if i > first {
+			start = 0
+		}
+
+		stats, err := trace.ReplayFile(ctx, file, exporter, append(opts,
+			trace.WithReplayOffset(start),
+			trace.WithReplayProgress(func(offset int64) {
+				if len(statePath) > 0 {
+					if err := writeState(statePath, replayState{File: file, Offset: offset}); err != nil {
+						log.Printf("[replay] save state error: %v", err)
+					}
+				}
+			}),
+		)...)
+		log.Printf("[replay] %s: %d spans in %d batches, offset %d", file, stats.Spans, stats.Batches, stats.Offset)
+		if err != nil {
+			return err
+		}
+		if len(statePath) == 0 {
+			continue
+		}
+
+		// the file is completed, the next one starts from the beginning.
+		if i+1 < len(files) {
+			if err = writeState(statePath, replayState{File: files[i+1]}); err != nil {
+				return err
+			}
+		} else if err = os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func indexOfFile(files []string, file string) int {
+	for i, f := range files {
+		if filepath.Clean(f) == filepath.Clean(file) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func parseHeaders(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid header: %s", pair)
+		}
+		out[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return out, nil
+}
+
+func readState(name string) (replayState, bool, error) {
+	var st replayState
+	b, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return st, false, nil
+	}
+	if err != nil {
+		return st, false, err
+	}
+
+	if err = json.Unmarshal(b, &st); err != nil {
+		return st, false, fmt.Errorf("invalid state %s: %s", name, err.Error())
+	}
+	return st, true, nil
+}
+
+func writeState(name string, st replayState) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/nextmicro/gokit/trace"
+	"github.com/stretchr/testify/assert"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errExport = errors.New("export")
+
+// failingExporter fails once it exported n batches.
+type failingExporter struct {
+	*tracetest.InMemoryExporter
+	n int
+}
+
+func (e *failingExporter) ExportSpans(ctx context.Context, spans []sdk.ReadOnlySpan) error {
+	if e.n == 0 {
+		return errExport
+	}
+
+	e.n--
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
+
+func writeSpanFile(t *testing.T, filename string, names ...string) {
+	exp, err := trace.NewFileExporter(filename, trace.FileConfig{Format: trace.FileFormatOtlpJson})
+	assert.Nil(t, err)
+
+	provider := sdk.NewTracerProvider(sdk.WithSyncer(exp))
+	tracer := provider.Tracer(trace.TraceName)
+	for _, name := range names {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	assert.Nil(t, provider.Shutdown(context.Background()))
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReplayFilesResume(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "1.json"), filepath.Join(dir, "2.json")}
+	writeSpanFile(t, files[0], "a1", "a2")
+	writeSpanFile(t, files[1], "b1", "b2", "b3")
+	statePath := filepath.Join(dir, "replay.state")
+	batch := trace.WithReplayBatchSize(1)
+
+	// interrupted in the second file, after b1.
+	exp := &failingExporter{InMemoryExporter: tracetest.NewInMemoryExporter(), n: 3}
+	err := replayFiles(context.Background(), files, exp, statePath, 0, batch)
+	assert.Equal(t, errExport, err)
+	assert.Equal(t, []string{"a1", "a2", "b1"}, spanNames(exp.GetSpans()))
+	st, ok, err := readState(statePath)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, files[1], st.File)
+	assert.True(t, st.Offset > 0)
+
+	// resumes the second file only.
+	memory := tracetest.NewInMemoryExporter()
+	assert.Nil(t, replayFiles(context.Background(), files, memory, statePath, 0, batch))
+	assert.Equal(t, []string{"b2", "b3"}, spanNames(memory.GetSpans()))
+	_, err = os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// starts over without a state.
+	memory.Reset()
+	assert.Nil(t, replayFiles(context.Background(), files, memory, statePath, 0, batch))
+	assert.Len(t, memory.GetSpans(), 5)
+}
+
+func TestReplayFilesCompletedFile(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "1.json"), filepath.Join(dir, "2.json")}
+	writeSpanFile(t, files[0], "a1")
+	writeSpanFile(t, files[1], "b1")
+	statePath := filepath.Join(dir, "replay.state")
+
+	// interrupted between the files.
+	exp := &failingExporter{InMemoryExporter: tracetest.NewInMemoryExporter(), n: 1}
+	assert.Equal(t, errExport, replayFiles(context.Background(), files, exp, statePath, 0))
+	st, ok, err := readState(statePath)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, replayState{File: files[1]}, st)
+
+	memory := tracetest.NewInMemoryExporter()
+	assert.Nil(t, replayFiles(context.Background(), files, memory, statePath, 0))
+	assert.Equal(t, []string{"b1"}, spanNames(memory.GetSpans()))
+}
+
+func TestReplayFilesUnknownState(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "1.json")
+	writeSpanFile(t, file, "a1")
+	statePath := filepath.Join(dir, "replay.state")
+	assert.Nil(t, writeState(statePath, replayState{File: "other.json", Offset: 10}))
+
+	err := replayFiles(context.Background(), []string{file}, tracetest.NewNoopExporter(), statePath, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,186 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is the validation error of a Config field.
+type FieldError struct {
+	// Field is the path of the field, for example file.maxSize.
+	Field   string
+	Message string
+}
+
+// Error returns the string representation of the error.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors are the field errors returned by Config.Validate.
+type ValidationErrors []FieldError
+
+// Error returns the string representation of the errors.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+
+	return "invalid trace config: " + strings.Join(msgs, "; ")
+}
+
+// endpointRequired lists the batchers which can not work without an endpoint.
+var endpointRequired = map[string]bool{
+	KindZipkin:   true,
+	KindOtlpGrpc: true,
+	KindOtlpHttp: true,
+	KindFile:     true,
+}
+
+// Validate checks the config, the returned error is ValidationErrors if not nil.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Sampler < 0 || c.Sampler > 1 {
+		add("sampler", "must be in [0, 1], got %v", c.Sampler)
+	}
+	for _, fe := range validateSamplingRules(c.SamplingRules) {
+		errs = append(errs, FieldError{Field: "samplingRules" + strings.TrimPrefix(fe.Field, "rules"), Message: fe.Message})
+	}
+	if _, ok := lookupExporter(c.Batcher); !ok {
+		add("batcher", "unknown exporter %q, registered: %s", c.Batcher, strings.Join(Exporters(), ", "))
+	}
+	if endpointRequired[c.Batcher] && len(c.Endpoint) == 0 {
+		add("endpoint", "is required by batcher %q", c.Batcher)
+	}
+	for _, name := range c.Detectors {
+		if _, ok := builtinDetectors[name]; !ok {
+			add("detectors", "unknown detector %q, available: %s", name, strings.Join(Detectors(), ", "))
+		}
+	}
+	if len(c.OtlpHttpPath) > 0 && !strings.HasPrefix(c.OtlpHttpPath, "/") {
+		add("otlpHttpPath", "must start with /, got %q", c.OtlpHttpPath)
+	}
+
+	if c.Redact != nil {
+		fields := []string{"redact.dropKeys", "redact.hashKeys", "redact.redactValues"}
+		for i, patterns := range [][]string{c.Redact.DropKeys, c.Redact.HashKeys, c.Redact.RedactValues} {
+			for _, p := range patterns {
+				if _, err := regexp.Compile(p); err != nil {
+					add(fields[i], "invalid pattern %q: %v", p, err)
+				}
+			}
+		}
+		if c.Redact.MaxValueLength < 0 {
+			add("redact.maxValueLength", "must not be negative")
+		}
+	}
+
+	if ts := c.TailSampling; ts != nil {
+		if r := ts.FallbackRatio; r != nil && (*r < 0 || *r > 1) {
+			add("tailSampling.fallbackRatio", "must be in [0, 1], got %v", *r)
+		}
+		if ts.DecisionWait < 0 {
+			add("tailSampling.decisionWait", "must not be negative")
+		}
+		if ts.LatencyThreshold < 0 {
+			add("tailSampling.latencyThreshold", "must not be negative")
+		}
+		if ts.MaxTraces < 0 {
+			add("tailSampling.maxTraces", "must not be negative")
+		}
+		if ts.MaxSpansInTrace < 0 {
+			add("tailSampling.maxSpansInTrace", "must not be negative")
+		}
+	}
+
+	if f := c.File; c.Batcher == KindFile {
+		if len(f.Format) > 0 && f.Format != FileFormatStdout && f.Format != FileFormatOtlpJson {
+			add("file.format", "must be %q or %q, got %q", FileFormatStdout, FileFormatOtlpJson, f.Format)
+		}
+		if f.MaxSize < 0 {
+			add("file.maxSize", "must not be negative")
+		}
+		if f.RotateInterval < 0 {
+			add("file.rotateInterval", "must not be negative")
+		}
+		if f.MaxBackups < 0 {
+			add("file.maxBackups", "must not be negative")
+		}
+	}
+
+	if c.Zipkin.Timeout < 0 {
+		add("zipkin.timeout", "must not be negative")
+	}
+	if (len(c.Zipkin.CertFile) > 0) != (len(c.Zipkin.KeyFile) > 0) {
+		add("zipkin.certFile", "certFile and keyFile must be set together")
+	}
+
+	if c.Spool != nil {
+		if len(c.Spool.Dir) == 0 {
+			add("spool.dir", "is required")
+		}
+		if c.Spool.MaxBytes < 0 {
+			add("spool.maxBytes", "must not be negative")
+		}
+		if c.Spool.RetryInterval < 0 {
+			add("spool.retryInterval", "must not be negative")
+		}
+		if c.Spool.MaxAttempts < 0 {
+			add("spool.maxAttempts", "must not be negative")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// LoadConfig reads a YAML or JSON config from r, validates it and fills the
+// defaults of New for the missing fields. Durations are written like 5s or 1m.
+func LoadConfig(r io.Reader) (Config, error) {
+	c := Config{
+		Sampler: 1.0,
+		Batcher: KindStdout,
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return c, err
+	}
+	// JSON is a subset of YAML, so both are decoded by the yaml decoder.
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err = dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("decode trace config error: %s", err.Error())
+	}
+	if err = c.Validate(); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// LoadConfigFile reads a YAML or JSON config from filename, see LoadConfig.
+func LoadConfigFile(filename string) (Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	return LoadConfig(f)
+}
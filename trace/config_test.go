@@ -0,0 +1,115 @@
+package trace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigYaml(t *testing.T) {
+	c, err := LoadConfig(strings.NewReader(`
+name: order-service
+endpoint: collector:4317
+sampler: 0.5
+batcher: otlpgrpc
+otlpHeaders:
+  x-token: secret
+redact:
+  redactValues: ['[a-z]+@example\.com']
+tailSampling:
+  decisionWait: 10s
+  latencyThreshold: 500ms
+file:
+  maxSize: 1048576
+  perm: 0600
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, "order-service", c.Name)
+	assert.Equal(t, "collector:4317", c.Endpoint)
+	assert.Equal(t, 0.5, c.Sampler)
+	assert.Equal(t, KindOtlpGrpc, c.Batcher)
+	assert.Equal(t, map[string]string{"x-token": "secret"}, c.OtlpHeaders)
+	assert.Equal(t, 10*time.Second, c.TailSampling.DecisionWait)
+	assert.Equal(t, 500*time.Millisecond, c.TailSampling.LatencyThreshold)
+	assert.Equal(t, os.FileMode(0600), c.File.Perm)
+	assert.Nil(t, c.Spool)
+}
+
+func TestLoadConfigJson(t *testing.T) {
+	c, err := LoadConfig(strings.NewReader(`{"batcher": "noop", "name": "json"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, KindNoop, c.Batcher)
+	assert.Equal(t, 1.0, c.Sampler)
+
+	tracing, err := New(WithConfig(c))
+	assert.Nil(t, err)
+	assert.Nil(t, tracing.Shutdown(context.Background()))
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "trace.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte("batcher: memory\n"), 0644))
+	c, err := LoadConfigFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, KindMemory, c.Batcher)
+
+	_, err = LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NotNil(t, err)
+
+	_, err = LoadConfig(strings.NewReader("unknown: 1\n"))
+	assert.NotNil(t, err)
+}
+
+func TestConfigValidate(t *testing.T) {
+	fallback := 2.0
+	c := Config{
+		Sampler:      1.5,
+		Batcher:      KindOtlpGrpc,
+		OtlpHttpPath: "v1/traces",
+		Redact:       &RedactConfig{DropKeys: []string{"("}},
+		TailSampling: &TailSamplingConfig{FallbackRatio: &fallback},
+		Spool:        &SpoolConfig{},
+	}
+	err := c.Validate()
+	assert.NotNil(t, err)
+
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	fields := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{
+		"sampler",
+		"endpoint",
+		"otlpHttpPath",
+		"redact.dropKeys",
+		"tailSampling.fallbackRatio",
+		"spool.dir",
+	}, fields)
+
+	c = Config{Sampler: 1, Batcher: "unknown"}
+	assert.Contains(t, c.Validate().Error(), "batcher")
+
+	c = Config{Sampler: 1, Batcher: KindStdout}
+	assert.Nil(t, c.Validate())
+}
+
+func TestNewValidates(t *testing.T) {
+	_, err := New(WithBatcher(KindOtlpGrpc))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "endpoint")
+
+	_, err = NewExporter(WithBatcher(KindZipkin))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "endpoint")
+
+	exp, err := NewExporter(WithBatcher(KindNoop))
+	assert.Nil(t, err)
+	assert.Nil(t, exp.Shutdown(context.Background()))
+}
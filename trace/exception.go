@@ -0,0 +1,211 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	exceptionEventName = "exception"
+	// maxStackFrames bounds the frames of exception.stacktrace.
+	maxStackFrames = 32
+
+	exceptionTypeKey       = attribute.Key("exception.type")
+	exceptionMessageKey    = attribute.Key("exception.message")
+	exceptionStacktraceKey = attribute.Key("exception.stacktrace")
+	exceptionEscapedKey    = attribute.Key("exception.escaped")
+)
+
+// An ErrorClassifier reports whether err marks the span as failed.
+type ErrorClassifier func(err error) bool
+
+// defaultClassifier holds the ErrorClassifier set by SetErrorClassifier.
+var defaultClassifier atomic.Value
+
+// SetErrorClassifier sets the ErrorClassifier used by RecordError and the
+// Tracers created without WithErrorClassifier, nil fails the spans on every error.
+func SetErrorClassifier(classifier ErrorClassifier) {
+	defaultClassifier.Store(classifier)
+}
+
+func loadErrorClassifier() ErrorClassifier {
+	classifier, _ := defaultClassifier.Load().(ErrorClassifier)
+	return classifier
+}
+
+// IgnoreErrors returns an ErrorClassifier which does not fail the spans on
+// the errors wrapping one of errs, as reported by errors.Is.
+func IgnoreErrors(errs ...error) ErrorClassifier {
+	return func(err error) bool {
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// IgnoreErrorTypes returns an ErrorClassifier which does not fail the spans
+// on the errors wrapping one of the types pointed by targets, as reported by
+// errors.As. For example IgnoreErrorTypes(new(*NotFoundError)).
+//
+// IgnoreErrorTypes panics if a target is not a non-nil pointer to a type
+// implementing error or to an interface type.
+func IgnoreErrorTypes(targets ...interface{}) ErrorClassifier {
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	types := make([]reflect.Type, 0, len(targets))
+	for _, target := range targets {
+		typ := reflect.TypeOf(target)
+		if typ == nil || typ.Kind() != reflect.Ptr || reflect.ValueOf(target).IsNil() {
+			panic("[otel] error type target must be a non-nil pointer")
+		}
+		if elem := typ.Elem(); elem.Kind() != reflect.Interface && !elem.Implements(errorType) {
+			panic(fmt.Sprintf("[otel] error type target *%s must be an interface or implement error", elem))
+		}
+		types = append(types, typ.Elem())
+	}
+
+	return func(err error) bool {
+		for _, typ := range types {
+			// a new target per call keeps the classifier safe for concurrent use.
+			if errors.As(err, reflect.New(typ).Interface()) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// IsFailure reports whether err fails the span, a nil ErrorClassifier fails
+// the span on every error.
+func (c ErrorClassifier) IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if c == nil {
+		return true
+	}
+
+	return c(err)
+}
+
+// RecordError records err as an exception event on span with a trimmed stack
+// trace, and sets the span status to error unless the classifier set by
+// SetErrorClassifier ignores err.
+func RecordError(span trace.Span, err error) {
+	recordException(span, err, loadErrorClassifier(), false, 1)
+}
+
+// RecordErrorFromContext records err on the span of ctx, see RecordError.
+func RecordErrorFromContext(ctx context.Context, err error) {
+	recordException(trace.SpanFromContext(ctx), err, loadErrorClassifier(), false, 1)
+}
+
+// RecordPanic records the panic recovered on the span of ctx, ends the span
+// and panics again. Ending the span first keeps the sdk from recording the
+// panic a second time. It must be deferred directly, after span.End:
+//
+//	ctx, span := tracer.Start(ctx, "name")
+//	defer span.End()
+//	defer trace.RecordPanic(ctx)
+func RecordPanic(ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	err, ok := r.(error)
+	if !ok {
+		err = &panicError{value: r}
+	}
+	span := trace.SpanFromContext(ctx)
+	recordException(span, err, nil, true, 1)
+	span.End()
+	panic(r)
+}
+
+// RecordError records err on span with the classifier of t, see RecordError.
+func (t *Tracer) RecordError(span trace.Span, err error) {
+	classifier := t.opt.classifier
+	if classifier == nil {
+		classifier = loadErrorClassifier()
+	}
+	recordException(span, err, classifier, false, 1)
+}
+
+// panicError wraps a recovered value which is not an error.
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprint(e.value)
+}
+
+// recordException records err on span, the stack trace starts skip frames
+// above the caller of recordException.
+func recordException(span trace.Span, err error, classifier ErrorClassifier, escaped bool, skip int) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	typ := fmt.Sprintf("%T", err)
+	if pe, ok := err.(*panicError); ok {
+		typ = fmt.Sprintf("%T", pe.value)
+	}
+	attrs := []attribute.KeyValue{
+		exceptionTypeKey.String(typ),
+		exceptionMessageKey.String(err.Error()),
+		exceptionStacktraceKey.String(stacktrace(skip + 1)),
+	}
+	if escaped {
+		attrs = append(attrs, exceptionEscapedKey.Bool(true))
+	}
+	span.AddEvent(exceptionEventName, trace.WithAttributes(attrs...))
+
+	if escaped || classifier.IsFailure(err) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// stacktrace returns the stack starting skip frames above the caller of
+// stacktrace, without the frames of the runtime package and with at most
+// maxStackFrames frames.
+func stacktrace(skip int) string {
+	pcs := make([]uintptr, maxStackFrames+16)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	var count int
+	for count < maxStackFrames {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte('\n')
+			count++
+		}
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
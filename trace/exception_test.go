@@ -0,0 +1,149 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type notFoundError struct {
+	key string
+}
+
+func (e *notFoundError) Error() string {
+	return e.key + " not found"
+}
+
+func newExceptionRecorder() (*tracetest.SpanRecorder, trace.Tracer) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(sdk.WithSpanProcessor(recorder))
+	return recorder, provider.Tracer("test")
+}
+
+func exceptionAttributes(t *testing.T, span sdk.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	events := span.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, exceptionEventName, events[0].Name)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range events[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestRecordError(t *testing.T) {
+	recorder, tracer := newExceptionRecorder()
+	ctx, span := tracer.Start(context.Background(), "query")
+	RecordErrorFromContext(ctx, fmt.Errorf("query users: %w", io.ErrUnexpectedEOF))
+	RecordError(span, nil)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "query users: unexpected EOF", spans[0].Status().Description)
+
+	attrs := exceptionAttributes(t, spans[0])
+	assert.Equal(t, "*fmt.wrapError", attrs[exceptionTypeKey].AsString())
+	assert.Equal(t, "query users: unexpected EOF", attrs[exceptionMessageKey].AsString())
+	stack := attrs[exceptionStacktraceKey].AsString()
+	assert.True(t, strings.HasPrefix(stack, "github.com/nextmicro/gokit/trace.TestRecordError\n"), stack)
+	assert.NotContains(t, stack, "recordException")
+	assert.NotContains(t, stack, "runtime.")
+}
+
+func TestErrorClassifier(t *testing.T) {
+	classifier := IgnoreErrorTypes(new(*notFoundError))
+	assert.False(t, classifier.IsFailure(fmt.Errorf("get: %w", &notFoundError{key: "user"})))
+	assert.True(t, classifier.IsFailure(io.EOF))
+	assert.False(t, classifier.IsFailure(nil))
+	assert.True(t, ErrorClassifier(nil).IsFailure(io.EOF))
+
+	classifier = IgnoreErrors(os.ErrNotExist)
+	assert.False(t, classifier.IsFailure(fmt.Errorf("open: %w", os.ErrNotExist)))
+	assert.True(t, classifier.IsFailure(os.ErrPermission))
+
+	assert.Panics(t, func() {
+		IgnoreErrorTypes(notFoundError{})
+	})
+	assert.Panics(t, func() {
+		IgnoreErrorTypes(new(string))
+	})
+}
+
+func TestTracerRecordError(t *testing.T) {
+	recorder, tracer := newExceptionRecorder()
+	tr := NewTracer(trace.SpanKindServer, WithErrorClassifier(IgnoreErrorTypes(new(*notFoundError))))
+
+	_, span := tracer.Start(context.Background(), "get")
+	tr.RecordError(span, &notFoundError{key: "order"})
+	span.End()
+	_, span = tracer.Start(context.Background(), "update")
+	tr.RecordError(span, io.ErrClosedPipe)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "*trace.notFoundError", exceptionAttributes(t, spans[0])[exceptionTypeKey].AsString())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestSetErrorClassifier(t *testing.T) {
+	SetErrorClassifier(IgnoreErrors(context.Canceled))
+	defer SetErrorClassifier(nil)
+
+	recorder, tracer := newExceptionRecorder()
+	_, span := tracer.Start(context.Background(), "canceled")
+	RecordError(span, context.Canceled)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestRecordPanic(t *testing.T) {
+	recorder, tracer := newExceptionRecorder()
+
+	panicky := func() {
+		ctx, span := tracer.Start(context.Background(), "panicky")
+		defer span.End()
+		defer RecordPanic(ctx)
+
+		panic("boom")
+	}
+	assert.PanicsWithValue(t, "boom", panicky)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	attrs := exceptionAttributes(t, spans[0])
+	assert.Equal(t, "string", attrs[exceptionTypeKey].AsString())
+	assert.Equal(t, "boom", attrs[exceptionMessageKey].AsString())
+	assert.True(t, attrs[exceptionEscapedKey].AsBool())
+	assert.True(t, strings.HasPrefix(attrs[exceptionStacktraceKey].AsString(),
+		"github.com/nextmicro/gokit/trace.TestRecordPanic.func1\n"))
+
+	notPanicky := func() {
+		ctx, span := tracer.Start(context.Background(), "calm")
+		defer span.End()
+		defer RecordPanic(ctx)
+	}
+	assert.NotPanics(t, notPanicky)
+
+	spans = recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Empty(t, spans[1].Events())
+}
@@ -0,0 +1,111 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// ExporterFactory creates the exporter of a Batcher kind from the config.
+type ExporterFactory func(*Config) (sdk.SpanExporter, error)
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = make(map[string]ExporterFactory)
+)
+
+func init() {
+	RegisterExporter(KindJaeger, newJaegerExporter)
+	RegisterExporter(KindZipkin, func(c *Config) (sdk.SpanExporter, error) {
+		return newZipkinExporter(c.Endpoint, c.Zipkin)
+	})
+	RegisterExporter(KindOtlpGrpc, newOtlpGrpcExporter)
+	RegisterExporter(KindOtlpHttp, newOtlpHttpExporter)
+	RegisterExporter(KindStdout, func(*Config) (sdk.SpanExporter, error) {
+		return stdouttrace.New()
+	})
+	RegisterExporter(KindFile, func(c *Config) (sdk.SpanExporter, error) {
+		return NewFileExporter(c.Endpoint, c.File)
+	})
+	RegisterExporter(KindNoop, func(*Config) (sdk.SpanExporter, error) {
+		return tracetest.NewNoopExporter(), nil
+	})
+	RegisterExporter(KindMemory, func(*Config) (sdk.SpanExporter, error) {
+		return tracetest.NewInMemoryExporter(), nil
+	})
+}
+
+// RegisterExporter makes the exporter created by factory selectable by the
+// Batcher option with the given kind. Registering an existing kind replaces it,
+// it is usually called in the init func of the package providing the exporter.
+func RegisterExporter(kind string, factory func(*Config) (sdk.SpanExporter, error)) {
+	if len(kind) == 0 {
+		panic("[otel] register exporter with empty kind")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("[otel] register nil exporter factory: %s", kind))
+	}
+
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[kind] = factory
+}
+
+// Exporters returns the registered Batcher kinds, sorted.
+func Exporters() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	kinds := make([]string, 0, len(exporters))
+	for kind := range exporters {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func lookupExporter(kind string) (ExporterFactory, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	factory, ok := exporters[kind]
+	return factory, ok
+}
+
+func newOtlpGrpcExporter(c *Config) (sdk.SpanExporter, error) {
+	// Always treat trace exporter as optional component, so we use nonblock here,
+	// otherwise this would slow down app start up even set a dial timeout here when
+	// endpoint can not reach.
+	// If the connection not dial success, the global otel ErrorHandler will catch error
+	// when reporting data like other exporters.
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithInsecure(),
+		otlptracegrpc.WithEndpoint(c.Endpoint),
+	}
+	if len(c.OtlpHeaders) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(c.OtlpHeaders))
+	}
+	return otlptracegrpc.New(context.Background(), opts...)
+}
+
+func newOtlpHttpExporter(c *Config) (sdk.SpanExporter, error) {
+	// Not support flexible configuration now.
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithInsecure(),
+		otlptracehttp.WithEndpoint(c.Endpoint),
+	}
+	if len(c.OtlpHeaders) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(c.OtlpHeaders))
+	}
+	if len(c.OtlpHttpPath) > 0 {
+		opts = append(opts, otlptracehttp.WithURLPath(c.OtlpHttpPath))
+	}
+	return otlptracehttp.New(context.Background(), opts...)
+}
@@ -0,0 +1,54 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// unregisterExporter removes the exporter of kind registered by a test.
+func unregisterExporter(kind string) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	delete(exporters, kind)
+}
+
+func TestRegisterExporter(t *testing.T) {
+	const kind = "custom"
+	memory := tracetest.NewInMemoryExporter()
+	var endpoint string
+	RegisterExporter(kind, func(c *Config) (sdk.SpanExporter, error) {
+		endpoint = c.Endpoint
+		return memory, nil
+	})
+	t.Cleanup(func() {
+		unregisterExporter(kind)
+	})
+	assert.Contains(t, Exporters(), kind)
+
+	exp, err := NewExporter(WithBatcher(kind), WithEndpoint("kafka:9092"))
+	assert.Nil(t, err)
+	assert.Equal(t, memory, exp)
+	assert.Equal(t, "kafka:9092", endpoint)
+
+	tracing, err := New(WithBatcher(kind))
+	assert.Nil(t, err)
+	assert.Equal(t, memory, tracing.Exporter())
+	assert.Nil(t, tracing.Shutdown(context.Background()))
+}
+
+func TestBuiltinExporters(t *testing.T) {
+	kinds := Exporters()
+	for _, kind := range []string{KindNoop, KindMemory, KindFile, KindStdout, KindZipkin, KindJaeger, KindOtlpGrpc, KindOtlpHttp} {
+		assert.Contains(t, kinds, kind)
+	}
+
+	_, err := NewExporter(WithBatcher("unknown"))
+	assert.NotNil(t, err)
+	assert.Panics(t, func() {
+		RegisterExporter("", nil)
+	})
+}
@@ -0,0 +1,117 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// FileFormatStdout writes one stdouttrace JSON span per line.
+	FileFormatStdout = "stdout"
+	// FileFormatOtlpJson writes one OTLP/JSON TracesData per line, which can be
+	// ingested by the collector filelog and otlpjsonfile receivers.
+	FileFormatOtlpJson = "otlpjson"
+
+	defaultFilePerm os.FileMode = 0644
+)
+
+// A FileConfig is the config of the file exporter used by KindFile.
+type FileConfig struct {
+	// Format is FileFormatStdout or FileFormatOtlpJson, defaults to FileFormatStdout.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// MaxSize rotates the file before it exceeds MaxSize bytes, zero disables it.
+	MaxSize int64 `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// RotateInterval rotates the file once it is older than RotateInterval, zero disables it.
+	RotateInterval time.Duration `json:"rotateInterval,omitempty" yaml:"rotateInterval,omitempty"`
+	// MaxBackups is the number of rotated files to retain, zero retains all of them.
+	MaxBackups int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+	// Compress gzips the rotated files.
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+	// Perm is the permission of the created files, defaults to 0644.
+	Perm os.FileMode `json:"perm,omitempty" yaml:"perm,omitempty"`
+}
+
+// fileExporter writes spans into a rotated file.
+type fileExporter struct {
+	writer *rotateWriter
+	format string
+
+	mu       sync.Mutex
+	stdout   *stdouttrace.Exporter
+	stopped  bool
+	stopOnce sync.Once
+}
+
+var _ sdk.SpanExporter = (*fileExporter)(nil)
+
+// NewFileExporter returns a sdk.SpanExporter writing spans into filename.
+func NewFileExporter(filename string, c FileConfig) (sdk.SpanExporter, error) {
+	if len(c.Format) == 0 {
+		c.Format = FileFormatStdout
+	}
+	if c.Format != FileFormatStdout && c.Format != FileFormatOtlpJson {
+		return nil, fmt.Errorf("unknown file exporter format: %s", c.Format)
+	}
+
+	w, err := newRotateWriter(filename, c)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &fileExporter{writer: w, format: c.Format}
+	if c.Format == FileFormatStdout {
+		if e.stdout, err = stdouttrace.New(stdouttrace.WithWriter(w)); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// ExportSpans writes spans into the file.
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []sdk.ReadOnlySpan) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopped {
+		return nil
+	}
+	if e.stdout != nil {
+		return e.stdout.ExportSpans(ctx, spans)
+	}
+
+	// a single Write keeps the whole line in the same file across rotations.
+	line, err := json.Marshal(otlpTracesFromSpans(spans))
+	if err != nil {
+		return err
+	}
+	_, err = e.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Shutdown closes the file.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	var err error
+	e.stopOnce.Do(func() {
+		e.mu.Lock()
+		e.stopped = true
+		e.mu.Unlock()
+		err = e.writer.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,88 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func exportTestSpans(t *testing.T, exp sdk.SpanExporter, names ...string) {
+	provider := sdk.NewTracerProvider(sdk.WithSyncer(exp))
+	tracer := provider.Tracer(TraceName)
+	for _, name := range names {
+		_, span := tracer.Start(context.Background(), name)
+		span.SetAttributes(attribute.Int("count", 1), attribute.StringSlice("tags", []string{"a", "b"}))
+		span.SetStatus(codes.Error, "failed")
+		span.End()
+	}
+	assert.Nil(t, provider.Shutdown(context.Background()))
+}
+
+func TestFileExporterOtlpJson(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "traces.json")
+	exp, err := NewFileExporter(filename, FileConfig{Format: FileFormatOtlpJson})
+	assert.Nil(t, err)
+	exportTestSpans(t, exp, "span1", "span2")
+
+	f, err := os.Open(filename)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+		var data map[string]interface{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &data))
+		rs := data["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		ss := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})
+		span := ss["spans"].([]interface{})[0].(map[string]interface{})
+		assert.Len(t, span["traceId"], 32)
+		assert.Len(t, span["spanId"], 16)
+		assert.Equal(t, float64(otlpStatusError), span["status"].(map[string]interface{})["code"])
+		attrs := span["attributes"].([]interface{})
+		assert.Equal(t, map[string]interface{}{"intValue": "1"}, attrs[0].(map[string]interface{})["value"])
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestFileExporterRotate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "traces.json")
+	exp, err := NewFileExporter(filename, FileConfig{
+		MaxSize:    1,
+		MaxBackups: 2,
+		Compress:   true,
+		Perm:       0600,
+	})
+	assert.Nil(t, err)
+	exportTestSpans(t, exp, "span1", "span2", "span3", "span4")
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	var backups int
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json.gz") {
+			backups++
+		}
+	}
+	assert.Equal(t, 2, backups)
+
+	info, err := os.Stat(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestFileExporterUnknownFormat(t *testing.T) {
+	_, err := NewFileExporter(filepath.Join(t.TempDir(), "traces.json"), FileConfig{Format: "xml"})
+	assert.NotNil(t, err)
+}
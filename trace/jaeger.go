@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// defaultJaegerEndpoint is the OTLP/HTTP receiver of Jaeger all-in-one.
+	defaultJaegerEndpoint = "localhost:4318"
+	// defaultJaegerPath is the OTLP/HTTP traces path of Jaeger.
+	defaultJaegerPath = "/v1/traces"
+)
+
+// newJaegerExporter exports to the Jaeger OTLP/HTTP receiver, Endpoint,
+// OtlpHeaders and OtlpHttpPath apply the same way as KindOtlpHttp.
+func newJaegerExporter(c *Config) (sdk.SpanExporter, error) {
+	endpoint := c.Endpoint
+	if len(endpoint) == 0 {
+		endpoint = defaultJaegerEndpoint
+	}
+	path := c.OtlpHttpPath
+	if len(path) == 0 {
+		path = defaultJaegerPath
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithInsecure(),
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithURLPath(path),
+	}
+	if len(c.OtlpHeaders) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(c.OtlpHeaders))
+	}
+
+	return otlptracehttp.New(context.Background(), opts...)
+}
+
+// JaegerPropagator returns the propagator of the Jaeger uber-trace-id header.
+func JaegerPropagator() jaeger.Jaeger {
+	return jaeger.Jaeger{}
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestJaeger(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, defaultJaegerPath, r.URL.Path)
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracing, err := New(WithBatcher(KindJaeger), WithName(TraceName),
+		WithEndpoint(strings.TrimPrefix(server.URL, "http://")))
+	assert.Nil(t, err)
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "uber-trace-id")
+	assert.Contains(t, tracing.Propagator().Fields(), "uber-trace-id")
+	// the propagator is not shared with the tracers of other Tracings.
+	assert.NotContains(t, NewTracer(trace.SpanKindClient).opt.propagator.Fields(), "uber-trace-id")
+
+	tracer := tracing.NewTracer(trace.SpanKindClient)
+	ctx, span := tracer.Start(context.Background(), "jaeger")
+
+	carrier := propagation.MapCarrier{}
+	tracer.Inject(ctx, carrier)
+	assert.NotEmpty(t, carrier.Get("uber-trace-id"))
+
+	ctx = tracer.Extract(context.Background(), propagation.MapCarrier{
+		"uber-trace-id": carrier.Get("uber-trace-id"),
+	})
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+
+	span.End()
+	assert.Nil(t, tracing.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
@@ -2,13 +2,17 @@ package trace
 
 import (
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
 )
 
 const (
 	KindNoop     = "noop"
+	KindMemory   = "memory"
 	KindFile     = "file"
 	KindStdout   = "stdout"
 	KindZipkin   = "zipkin"
+	KindJaeger   = "jaeger"
 	KindOtlpGrpc = "otlpgrpc"
 	KindOtlpHttp = "otlphttp"
 	// TraceName represents the tracing name.
@@ -21,18 +25,52 @@ type Option interface {
 
 // A Config is a opentelemetry config.
 type Config struct {
-	Endpoint string
-	Sampler  float64
-	Batcher  string
+	// Name is the service name, the same as WithName.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Version is the service.version, the same as WithVersion.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Namespace is the service.namespace, the same as WithNamespace.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// InstanceId is the service.instance.id, defaults to the detected one, or a random UUID.
+	InstanceId string `json:"instanceId,omitempty" yaml:"instanceId,omitempty"`
+	// Environment is the deployment.environment, like production or staging.
+	Environment string `json:"environment,omitempty" yaml:"environment,omitempty"`
+
+	Endpoint string  `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	Sampler  float64 `json:"sampler" yaml:"sampler"`
+	Batcher  string  `json:"batcher" yaml:"batcher"`
+	// SamplingRules override Sampler for the root spans they match, they can
+	// be changed at runtime by Tracing.UpdateSampler.
+	SamplingRules []SamplingRule `json:"samplingRules,omitempty" yaml:"samplingRules,omitempty"`
 	// OtlpHeaders represents the headers for OTLP gRPC or HTTP transport.
 	// For example:
 	//  uptrace-dsn: 'http://project2_secret_token@localhost:14317/2'
-	OtlpHeaders map[string]string
+	OtlpHeaders map[string]string `json:"otlpHeaders,omitempty" yaml:"otlpHeaders,omitempty"`
 	// OtlpHttpPath represents the path for OTLP HTTP transport.
 	// For example
 	// /v1/traces
-	OtlpHttpPath string
-	Attributes   []attribute.KeyValue
+	OtlpHttpPath string               `json:"otlpHttpPath,omitempty" yaml:"otlpHttpPath,omitempty"`
+	Attributes   []attribute.KeyValue `json:"-" yaml:"-"`
+	// Detectors are the names of the built-in resource detectors to run, like
+	// DetectorHost or DetectorK8s. Nil runs DetectorOS, DetectorHost, DetectorEnv,
+	// DetectorProcess and DetectorTelemetrySDK, an empty slice runs none.
+	Detectors []string `json:"detectors,omitempty" yaml:"detectors,omitempty"`
+	// ResourceDetectors are custom detectors run after the built-in ones.
+	ResourceDetectors []resource.Detector `json:"-" yaml:"-"`
+	// SpanProcessors are registered on the TracerProvider before the batcher.
+	SpanProcessors []sdk.SpanProcessor `json:"-" yaml:"-"`
+	// Redact filters span attributes before they are exported, nil disables it.
+	Redact *RedactConfig `json:"redact,omitempty" yaml:"redact,omitempty"`
+	// TailSampling buffers spans per trace and decides which traces are exported,
+	// nil disables it. Keep Sampler at 1.0 so that the decision sees all spans.
+	TailSampling *TailSamplingConfig `json:"tailSampling,omitempty" yaml:"tailSampling,omitempty"`
+	// Zipkin configures the KindZipkin exporter.
+	Zipkin ZipkinConfig `json:"zipkin,omitempty" yaml:"zipkin,omitempty"`
+	// File configures the KindFile exporter, which writes to Endpoint.
+	File FileConfig `json:"file,omitempty" yaml:"file,omitempty"`
+	// Spool stores the batches failed to export on disk and replays them later,
+	// nil disables it.
+	Spool *SpoolConfig `json:"spool,omitempty" yaml:"spool,omitempty"`
 }
 
 type OptionFunc func(*Config)
@@ -44,7 +82,35 @@ func (fn OptionFunc) apply(cfg *Config) {
 // WithName sets the service name.
 func WithName(name string) Option {
 	return OptionFunc(func(o *Config) {
-		o.Attributes = append(o.Attributes, attribute.Key("service.name").String(name))
+		o.Name = name
+	})
+}
+
+// WithVersion sets the service version.
+func WithVersion(version string) Option {
+	return OptionFunc(func(o *Config) {
+		o.Version = version
+	})
+}
+
+// WithNamespace sets the service namespace.
+func WithNamespace(namespace string) Option {
+	return OptionFunc(func(o *Config) {
+		o.Namespace = namespace
+	})
+}
+
+// WithInstanceId sets the service instance id, which defaults to the detected one, or a random UUID.
+func WithInstanceId(id string) Option {
+	return OptionFunc(func(o *Config) {
+		o.InstanceId = id
+	})
+}
+
+// WithEnvironment sets the deployment environment.
+func WithEnvironment(env string) Option {
+	return OptionFunc(func(o *Config) {
+		o.Environment = env
 	})
 }
 
@@ -62,6 +128,13 @@ func WithSampler(sampler float64) Option {
 	})
 }
 
+// WithSamplingRules sets the sampling rules.
+func WithSamplingRules(rules ...SamplingRule) Option {
+	return OptionFunc(func(o *Config) {
+		o.SamplingRules = rules
+	})
+}
+
 // WithBatcher sets the batcher.
 func WithBatcher(batcher string) Option {
 	return OptionFunc(func(o *Config) {
@@ -86,6 +159,70 @@ func WithOtlpHttpPath(path string) Option {
 // WithAttributes adds attributes to the configured Resource.
 func WithAttributes(attributes ...attribute.KeyValue) Option {
 	return OptionFunc(func(o *Config) {
-		o.Attributes = attributes
+		o.Attributes = append(o.Attributes, attributes...)
+	})
+}
+
+// WithDetectors sets the built-in resource detectors to run, see Config.Detectors.
+func WithDetectors(names ...string) Option {
+	return OptionFunc(func(o *Config) {
+		o.Detectors = append([]string{}, names...)
+	})
+}
+
+// WithResourceDetectors adds custom resource detectors.
+func WithResourceDetectors(detectors ...resource.Detector) Option {
+	return OptionFunc(func(o *Config) {
+		o.ResourceDetectors = append(o.ResourceDetectors, detectors...)
+	})
+}
+
+// WithSpanProcessor registers span processors on the TracerProvider.
+func WithSpanProcessor(processors ...sdk.SpanProcessor) Option {
+	return OptionFunc(func(o *Config) {
+		o.SpanProcessors = append(o.SpanProcessors, processors...)
+	})
+}
+
+// WithRedact sets the compliance filter applied before spans are exported.
+func WithRedact(c RedactConfig) Option {
+	return OptionFunc(func(o *Config) {
+		o.Redact = &c
+	})
+}
+
+// WithTailSampling enables the in-process tail sampling.
+func WithTailSampling(c TailSamplingConfig) Option {
+	return OptionFunc(func(o *Config) {
+		o.TailSampling = &c
+	})
+}
+
+// WithFile sets the config of the KindFile exporter.
+func WithFile(c FileConfig) Option {
+	return OptionFunc(func(o *Config) {
+		o.File = c
+	})
+}
+
+// WithSpool enables the disk spool of failed span batches.
+func WithSpool(c SpoolConfig) Option {
+	return OptionFunc(func(o *Config) {
+		o.Spool = &c
+	})
+}
+
+// WithZipkin sets the config of the KindZipkin exporter.
+func WithZipkin(c ZipkinConfig) Option {
+	return OptionFunc(func(o *Config) {
+		o.Zipkin = c
+	})
+}
+
+// WithConfig replaces the whole config by c, for example a config loaded by
+// LoadConfig. Options given after it still apply.
+func WithConfig(c Config) Option {
+	return OptionFunc(func(o *Config) {
+		*o = c
 	})
 }
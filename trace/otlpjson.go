@@ -0,0 +1,258 @@
+package trace
+
+import (
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// The types below follow the OTLP/JSON encoding of opentelemetry-proto TracesData:
+// lowerCamelCase field names, hex encoded ids, 64 bit integers as strings and
+// enums as integers.
+type (
+	otlpTracesData struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+		SchemaUrl  string           `json:"schemaUrl,omitempty"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpScopeSpans struct {
+		Scope     otlpScope  `json:"scope"`
+		Spans     []otlpSpan `json:"spans"`
+		SchemaUrl string     `json:"schemaUrl,omitempty"`
+	}
+
+	otlpScope struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceId                string         `json:"traceId"`
+		SpanId                 string         `json:"spanId"`
+		TraceState             string         `json:"traceState,omitempty"`
+		ParentSpanId           string         `json:"parentSpanId,omitempty"`
+		Flags                  uint32         `json:"flags,omitempty"`
+		Name                   string         `json:"name"`
+		Kind                   int            `json:"kind,omitempty"`
+		StartTimeUnixNano      string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano        string         `json:"endTimeUnixNano"`
+		Attributes             []otlpKeyValue `json:"attributes,omitempty"`
+		DroppedAttributesCount uint32         `json:"droppedAttributesCount,omitempty"`
+		Events                 []otlpEvent    `json:"events,omitempty"`
+		DroppedEventsCount     uint32         `json:"droppedEventsCount,omitempty"`
+		Links                  []otlpLink     `json:"links,omitempty"`
+		DroppedLinksCount      uint32         `json:"droppedLinksCount,omitempty"`
+		Status                 otlpStatus     `json:"status"`
+	}
+
+	otlpEvent struct {
+		TimeUnixNano           string         `json:"timeUnixNano"`
+		Name                   string         `json:"name"`
+		Attributes             []otlpKeyValue `json:"attributes,omitempty"`
+		DroppedAttributesCount uint32         `json:"droppedAttributesCount,omitempty"`
+	}
+
+	otlpLink struct {
+		TraceId                string         `json:"traceId"`
+		SpanId                 string         `json:"spanId"`
+		TraceState             string         `json:"traceState,omitempty"`
+		Attributes             []otlpKeyValue `json:"attributes,omitempty"`
+		DroppedAttributesCount uint32         `json:"droppedAttributesCount,omitempty"`
+		Flags                  uint32         `json:"flags,omitempty"`
+	}
+
+	otlpStatus struct {
+		Message string `json:"message,omitempty"`
+		Code    int    `json:"code,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string         `json:"stringValue,omitempty"`
+		BoolValue   *bool           `json:"boolValue,omitempty"`
+		IntValue    *string         `json:"intValue,omitempty"`
+		DoubleValue *float64        `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	}
+
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+)
+
+// OTLP status codes, which differ from the codes package.
+const (
+	otlpStatusUnset = 0
+	otlpStatusOk    = 1
+	otlpStatusError = 2
+)
+
+// otlpTracesFromSpans groups spans by resource and instrumentation scope.
+func otlpTracesFromSpans(spans []sdk.ReadOnlySpan) otlpTracesData {
+	var data otlpTracesData
+	resources := make(map[attribute.Distinct]int)
+	scopes := make(map[attribute.Distinct]map[instrumentation.Scope]int)
+
+	for _, s := range spans {
+		res := s.Resource()
+		if res == nil {
+			res = resource.Empty()
+		}
+		key := res.Equivalent()
+		ri, ok := resources[key]
+		if !ok {
+			ri = len(data.ResourceSpans)
+			resources[key] = ri
+			scopes[key] = make(map[instrumentation.Scope]int)
+			data.ResourceSpans = append(data.ResourceSpans, otlpResourceSpans{
+				Resource:  otlpResource{Attributes: otlpAttributes(res.Attributes())},
+				SchemaUrl: res.SchemaURL(),
+			})
+		}
+
+		rs := &data.ResourceSpans[ri]
+		scope := s.InstrumentationScope()
+		si, ok := scopes[key][scope]
+		if !ok {
+			si = len(rs.ScopeSpans)
+			scopes[key][scope] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{
+				Scope:     otlpScope{Name: scope.Name, Version: scope.Version},
+				SchemaUrl: scope.SchemaURL,
+			})
+		}
+
+		ss := &rs.ScopeSpans[si]
+		ss.Spans = append(ss.Spans, otlpSpanFromReadOnlySpan(s))
+	}
+
+	return data
+}
+
+func otlpSpanFromReadOnlySpan(s sdk.ReadOnlySpan) otlpSpan {
+	sc := s.SpanContext()
+	out := otlpSpan{
+		TraceId:                sc.TraceID().String(),
+		SpanId:                 sc.SpanID().String(),
+		TraceState:             sc.TraceState().String(),
+		Flags:                  uint32(sc.TraceFlags()),
+		Name:                   s.Name(),
+		Kind:                   int(s.SpanKind()),
+		StartTimeUnixNano:      strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:        strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:             otlpAttributes(s.Attributes()),
+		DroppedAttributesCount: uint32(s.DroppedAttributes()),
+		DroppedEventsCount:     uint32(s.DroppedEvents()),
+		DroppedLinksCount:      uint32(s.DroppedLinks()),
+		Status:                 otlpStatusFromStatus(s.Status()),
+	}
+	if s.Parent().HasSpanID() {
+		out.ParentSpanId = s.Parent().SpanID().String()
+	}
+
+	for _, e := range s.Events() {
+		out.Events = append(out.Events, otlpEvent{
+			TimeUnixNano:           strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:                   e.Name,
+			Attributes:             otlpAttributes(e.Attributes),
+			DroppedAttributesCount: uint32(e.DroppedAttributeCount),
+		})
+	}
+	for _, l := range s.Links() {
+		out.Links = append(out.Links, otlpLink{
+			TraceId:                l.SpanContext.TraceID().String(),
+			SpanId:                 l.SpanContext.SpanID().String(),
+			TraceState:             l.SpanContext.TraceState().String(),
+			Attributes:             otlpAttributes(l.Attributes),
+			DroppedAttributesCount: uint32(l.DroppedAttributeCount),
+			Flags:                  uint32(l.SpanContext.TraceFlags()),
+		})
+	}
+
+	return out
+}
+
+func otlpStatusFromStatus(status sdk.Status) otlpStatus {
+	out := otlpStatus{Message: status.Description}
+	switch status.Code {
+	case codes.Ok:
+		out.Code = otlpStatusOk
+	case codes.Error:
+		out.Code = otlpStatusError
+	default:
+		out.Code = otlpStatusUnset
+	}
+
+	return out
+}
+
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, otlpKeyValue{Key: string(kv.Key), Value: otlpValue(kv.Value)})
+	}
+
+	return out
+}
+
+func otlpValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		values := make([]otlpAnyValue, 0)
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, otlpValue(attribute.BoolValue(b)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := make([]otlpAnyValue, 0)
+		for _, i := range v.AsInt64Slice() {
+			values = append(values, otlpValue(attribute.Int64Value(i)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := make([]otlpAnyValue, 0)
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, otlpValue(attribute.Float64Value(f)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := make([]otlpAnyValue, 0)
+		for _, s := range v.AsStringSlice() {
+			values = append(values, otlpValue(attribute.StringValue(s)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		s := v.Emit()
+		return otlpAnyValue{StringValue: &s}
+	}
+}
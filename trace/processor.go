@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextExtractor reads values from the context and returns them as span attributes.
+type ContextExtractor func(ctx context.Context) []attribute.KeyValue
+
+// ContextAttributesOption is context attributes processor option.
+type ContextAttributesOption func(*contextAttributesOptions)
+
+type contextAttributesOptions struct {
+	attributes []attribute.KeyValue
+	extractors []ContextExtractor
+	rules      []kindRule
+}
+
+// kindRule adds attributes only to spans of the given kind.
+type kindRule struct {
+	kind       trace.SpanKind
+	attributes []attribute.KeyValue
+	extractors []ContextExtractor
+}
+
+// ContextAttributesProcessor is a sdk.SpanProcessor that stamps configured
+// context values and static attributes onto every span when it starts.
+type ContextAttributesProcessor struct {
+	opt *contextAttributesOptions
+}
+
+var _ sdk.SpanProcessor = (*ContextAttributesProcessor)(nil)
+
+// WithStaticAttributes adds attributes to every span.
+func WithStaticAttributes(attributes ...attribute.KeyValue) ContextAttributesOption {
+	return func(opts *contextAttributesOptions) {
+		opts.attributes = append(opts.attributes, attributes...)
+	}
+}
+
+// WithContextExtractor registers extractors that run for every span.
+func WithContextExtractor(extractors ...ContextExtractor) ContextAttributesOption {
+	return func(opts *contextAttributesOptions) {
+		opts.extractors = append(opts.extractors, extractors...)
+	}
+}
+
+// WithKindAttributes adds attributes only to spans of the given kind.
+func WithKindAttributes(kind trace.SpanKind, attributes ...attribute.KeyValue) ContextAttributesOption {
+	return func(opts *contextAttributesOptions) {
+		opts.rules = append(opts.rules, kindRule{kind: kind, attributes: attributes})
+	}
+}
+
+// WithKindExtractor registers extractors that run only for spans of the given kind.
+func WithKindExtractor(kind trace.SpanKind, extractors ...ContextExtractor) ContextAttributesOption {
+	return func(opts *contextAttributesOptions) {
+		opts.rules = append(opts.rules, kindRule{kind: kind, extractors: extractors})
+	}
+}
+
+// NewContextAttributesProcessor create context attributes processor instance.
+func NewContextAttributesProcessor(opts ...ContextAttributesOption) *ContextAttributesProcessor {
+	op := contextAttributesOptions{}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	return &ContextAttributesProcessor{opt: &op}
+}
+
+// OnStart adds the configured attributes to the span.
+func (p *ContextAttributesProcessor) OnStart(parent context.Context, s sdk.ReadWriteSpan) {
+	attrs := make([]attribute.KeyValue, 0, len(p.opt.attributes))
+	attrs = append(attrs, p.opt.attributes...)
+	for _, extract := range p.opt.extractors {
+		attrs = append(attrs, extract(parent)...)
+	}
+
+	kind := s.SpanKind()
+	for _, rule := range p.opt.rules {
+		if rule.kind != kind {
+			continue
+		}
+		attrs = append(attrs, rule.attributes...)
+		for _, extract := range rule.extractors {
+			attrs = append(attrs, extract(parent)...)
+		}
+	}
+
+	if len(attrs) > 0 {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// OnEnd does nothing.
+func (p *ContextAttributesProcessor) OnEnd(sdk.ReadOnlySpan) {}
+
+// Shutdown does nothing.
+func (p *ContextAttributesProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+// ForceFlush does nothing.
+func (p *ContextAttributesProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// ContextValue returns a ContextExtractor that reads ctx.Value(ctxKey) and
+// stores it under the given attribute key. Nil values are skipped.
+func ContextValue(key attribute.Key, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context) []attribute.KeyValue {
+		if ctx == nil {
+			return nil
+		}
+
+		v := ctx.Value(ctxKey)
+		if v == nil {
+			return nil
+		}
+
+		return []attribute.KeyValue{attributeOf(key, v)}
+	}
+}
+
+func attributeOf(key attribute.Key, v interface{}) attribute.KeyValue {
+	switch val := v.(type) {
+	case string:
+		return key.String(val)
+	case bool:
+		return key.Bool(val)
+	case int:
+		return key.Int(val)
+	case int64:
+		return key.Int64(val)
+	case float64:
+		return key.Float64(val)
+	case []string:
+		return key.StringSlice(val)
+	case fmt.Stringer:
+		return key.String(val.String())
+	default:
+		return key.String(fmt.Sprint(val))
+	}
+}
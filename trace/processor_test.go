@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tenantKey struct{}
+
+func attributesOf(span sdk.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	out := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		out[kv.Key] = kv.Value
+	}
+	return out
+}
+
+func TestContextAttributesProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdk.NewTracerProvider(
+		sdk.WithSpanProcessor(NewContextAttributesProcessor(
+			WithStaticAttributes(attribute.String("region", "cn-east")),
+			WithContextExtractor(ContextValue("tenant", tenantKey{})),
+			WithKindAttributes(trace.SpanKindServer, attribute.Bool("entry", true)),
+		)),
+		sdk.WithSpanProcessor(recorder),
+	)
+	defer provider.Shutdown(context.Background())
+
+	tracer := provider.Tracer(TraceName)
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	_, server := tracer.Start(ctx, "server", trace.WithSpanKind(trace.SpanKindServer))
+	server.End()
+	_, client := tracer.Start(context.Background(), "client", trace.WithSpanKind(trace.SpanKindClient))
+	client.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	attrs := attributesOf(spans[0])
+	assert.Equal(t, "cn-east", attrs["region"].AsString())
+	assert.Equal(t, "acme", attrs["tenant"].AsString())
+	assert.True(t, attrs["entry"].AsBool())
+
+	attrs = attributesOf(spans[1])
+	assert.Equal(t, "cn-east", attrs["region"].AsString())
+	_, ok := attrs["tenant"]
+	assert.False(t, ok)
+	_, ok = attrs["entry"]
+	assert.False(t, ok)
+}
+
+func TestContextValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tenantKey{}, 42)
+	kvs := ContextValue("tenant", tenantKey{})(ctx)
+	assert.Equal(t, []attribute.KeyValue{attribute.Int("tenant", 42)}, kvs)
+	assert.Nil(t, ContextValue("tenant", tenantKey{})(context.Background()))
+}
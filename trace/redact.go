@@ -0,0 +1,185 @@
+package trace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// RedactEmail matches email addresses.
+	RedactEmail = `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`
+	// RedactCardNumber matches 13 to 19 digit card numbers, optionally separated by spaces or dashes.
+	RedactCardNumber = `\b(?:\d[ \-]?){12,18}\d\b`
+
+	defaultRedactReplacement = "[REDACTED]"
+)
+
+// A RedactConfig is the compliance filter applied to spans before they are exported.
+type RedactConfig struct {
+	// DropKeys are regular expressions, attributes whose key matches are removed.
+	DropKeys []string `json:"dropKeys,omitempty" yaml:"dropKeys,omitempty"`
+	// HashKeys are regular expressions, attributes whose key matches are replaced
+	// by the hex sha256 of their value.
+	HashKeys []string `json:"hashKeys,omitempty" yaml:"hashKeys,omitempty"`
+	// MaxValueLength truncates string values longer than it in bytes, at a rune
+	// boundary, zero means no limit.
+	MaxValueLength int `json:"maxValueLength,omitempty" yaml:"maxValueLength,omitempty"`
+	// RedactValues are regular expressions, matched parts of string values are
+	// replaced by Replacement. For example RedactEmail and RedactCardNumber.
+	RedactValues []string `json:"redactValues,omitempty" yaml:"redactValues,omitempty"`
+	// Replacement replaces redacted values, defaults to [REDACTED].
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+// redactExporter filters span attributes before handing spans to the wrapped exporter.
+type redactExporter struct {
+	exporter    sdk.SpanExporter
+	dropKeys    []*regexp.Regexp
+	hashKeys    []*regexp.Regexp
+	values      []*regexp.Regexp
+	maxLength   int
+	replacement string
+}
+
+var _ sdk.SpanExporter = (*redactExporter)(nil)
+
+// NewRedactExporter returns a sdk.SpanExporter that applies c to every span
+// before passing it to exporter.
+func NewRedactExporter(exporter sdk.SpanExporter, c RedactConfig) (sdk.SpanExporter, error) {
+	e := &redactExporter{
+		exporter:    exporter,
+		maxLength:   c.MaxValueLength,
+		replacement: c.Replacement,
+	}
+	if len(e.replacement) == 0 {
+		e.replacement = defaultRedactReplacement
+	}
+
+	var err error
+	if e.dropKeys, err = compilePatterns(c.DropKeys); err != nil {
+		return nil, err
+	}
+	if e.hashKeys, err = compilePatterns(c.HashKeys); err != nil {
+		return nil, err
+	}
+	if e.values, err = compilePatterns(c.RedactValues); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q error: %s", p, err.Error())
+		}
+		out = append(out, re)
+	}
+
+	return out, nil
+}
+
+// ExportSpans filters spans and exports them with the wrapped exporter.
+func (e *redactExporter) ExportSpans(ctx context.Context, spans []sdk.ReadOnlySpan) error {
+	out := make([]sdk.ReadOnlySpan, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, e.redactSpan(s))
+	}
+
+	return e.exporter.ExportSpans(ctx, out)
+}
+
+// Shutdown shuts down the wrapped exporter.
+func (e *redactExporter) Shutdown(ctx context.Context) error {
+	return e.exporter.Shutdown(ctx)
+}
+
+func (e *redactExporter) redactSpan(s sdk.ReadOnlySpan) sdk.ReadOnlySpan {
+	events := s.Events()
+	redactedEvents := make([]sdk.Event, 0, len(events))
+	for _, ev := range events {
+		ev.Attributes = e.redactAttributes(ev.Attributes)
+		redactedEvents = append(redactedEvents, ev)
+	}
+
+	return &redactedSpan{
+		ReadOnlySpan: s,
+		attributes:   e.redactAttributes(s.Attributes()),
+		events:       redactedEvents,
+	}
+}
+
+func (e *redactExporter) redactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		key := string(kv.Key)
+		if matchAny(e.dropKeys, key) {
+			continue
+		}
+		if matchAny(e.hashKeys, key) {
+			sum := sha256.Sum256([]byte(kv.Value.Emit()))
+			out = append(out, kv.Key.String(hex.EncodeToString(sum[:])))
+			continue
+		}
+		if kv.Value.Type() == attribute.STRING {
+			out = append(out, kv.Key.String(e.redactString(kv.Value.AsString())))
+			continue
+		}
+		out = append(out, kv)
+	}
+
+	return out
+}
+
+func (e *redactExporter) redactString(v string) string {
+	for _, re := range e.values {
+		v = re.ReplaceAllString(v, e.replacement)
+	}
+	if e.maxLength > 0 && len(v) > e.maxLength {
+		// cuts at a rune boundary, so that the value stays valid UTF-8.
+		n := e.maxLength
+		for n > 0 && !utf8.RuneStart(v[n]) {
+			n--
+		}
+		v = v[:n]
+	}
+
+	return v
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactedSpan overrides the attributes and events of a sdk.ReadOnlySpan.
+type redactedSpan struct {
+	sdk.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdk.Event
+}
+
+// Attributes returns the redacted attributes.
+func (s *redactedSpan) Attributes() []attribute.KeyValue {
+	return s.attributes
+}
+
+// Events returns the events with redacted attributes.
+func (s *redactedSpan) Events() []sdk.Event {
+	return s.events
+}
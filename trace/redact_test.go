@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactExporter(t *testing.T) {
+	memory := tracetest.NewInMemoryExporter()
+	exp, err := NewRedactExporter(memory, RedactConfig{
+		DropKeys:       []string{`^http\.request\.header\.authorization$`},
+		HashKeys:       []string{`^user\.id$`},
+		MaxValueLength: 16,
+		RedactValues:   []string{RedactEmail, RedactCardNumber},
+	})
+	assert.Nil(t, err)
+
+	provider := sdk.NewTracerProvider(sdk.WithSyncer(exp))
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer(TraceName).Start(context.Background(), "redact")
+	span.SetAttributes(
+		attribute.String("http.request.header.authorization", "Bearer token"),
+		attribute.String("user.id", "1001"),
+		attribute.String("email", "foo@example.com"),
+		attribute.String("card", "4111 1111 1111 1111"),
+		attribute.String("long", "abcdefghijklmnopqrstuvwxyz"),
+		attribute.Int("count", 3),
+	)
+	span.AddEvent("login", trace.WithAttributes(attribute.String("email", "bar@example.com")))
+	span.End()
+
+	spans := memory.GetSpans()
+	assert.Len(t, spans, 1)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	_, ok := attrs["http.request.header.authorization"]
+	assert.False(t, ok)
+	sum := sha256.Sum256([]byte("1001"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), attrs["user.id"].AsString())
+	assert.Equal(t, "[REDACTED]", attrs["email"].AsString())
+	assert.Equal(t, "[REDACTED]", attrs["card"].AsString())
+	assert.Equal(t, "abcdefghijklmnop", attrs["long"].AsString())
+	assert.Equal(t, int64(3), attrs["count"].AsInt64())
+
+	assert.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "[REDACTED]", spans[0].Events[0].Attributes[0].Value.AsString())
+}
+
+func TestRedactExporterInvalidPattern(t *testing.T) {
+	_, err := NewRedactExporter(tracetest.NewNoopExporter(), RedactConfig{DropKeys: []string{"("}})
+	assert.NotNil(t, err)
+}
+
+func TestRedactExporterTruncateRunes(t *testing.T) {
+	exp, err := NewRedactExporter(tracetest.NewNoopExporter(), RedactConfig{MaxValueLength: 5})
+	assert.Nil(t, err)
+
+	r := exp.(*redactExporter)
+	// each rune is 3 bytes, the second one does not fit.
+	assert.Equal(t, "日", r.redactString("日本語"))
+	assert.Equal(t, "ab日", r.redactString("ab日本"))
+	assert.Equal(t, "abcde", r.redactString("abcdef"))
+	assert.True(t, utf8.ValidString(r.redactString("héllo wörld")))
+}
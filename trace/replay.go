@@ -0,0 +1,634 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultReplayBatchSize = 512
+
+type (
+	// ReplayOption is replay option.
+	ReplayOption func(*replayOptions)
+
+	replayOptions struct {
+		rate      int
+		offset    int64
+		batchSize int
+		progress  func(offset int64)
+	}
+
+	// ReplayStats is the result of a replay.
+	ReplayStats struct {
+		// Spans is the number of exported spans.
+		Spans int
+		// Batches is the number of exported batches.
+		Batches int
+		// Offset is the byte offset of the first line not exported yet,
+		// pass it to WithReplayOffset to resume.
+		Offset int64
+	}
+
+	// SpanReader reads spans written by the KindFile exporter, both
+	// FileFormatStdout and FileFormatOtlpJson lines are accepted.
+	SpanReader struct {
+		r      *bufio.Reader
+		offset int64
+	}
+)
+
+// WithReplayRate limits the replay to n spans per second, zero means no limit.
+func WithReplayRate(n int) ReplayOption {
+	return func(opts *replayOptions) {
+		opts.rate = n
+	}
+}
+
+// WithReplayOffset resumes the replay from the given byte offset.
+func WithReplayOffset(offset int64) ReplayOption {
+	return func(opts *replayOptions) {
+		opts.offset = offset
+	}
+}
+
+// WithReplayBatchSize sets the max number of spans exported at once.
+func WithReplayBatchSize(n int) ReplayOption {
+	return func(opts *replayOptions) {
+		opts.batchSize = n
+	}
+}
+
+// WithReplayProgress sets the func called with the resume offset after each exported batch.
+func WithReplayProgress(fn func(offset int64)) ReplayOption {
+	return func(opts *replayOptions) {
+		opts.progress = fn
+	}
+}
+
+// NewSpanReader returns a SpanReader reading from r.
+func NewSpanReader(r io.Reader) *SpanReader {
+	return &SpanReader{r: bufio.NewReader(r)}
+}
+
+// Offset returns the number of bytes consumed so far.
+func (r *SpanReader) Offset() int64 {
+	return r.offset
+}
+
+// Next returns the spans of the next non-empty line, io.EOF is returned at the end.
+func (r *SpanReader) Next() ([]sdk.ReadOnlySpan, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		r.offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			spans, perr := parseSpanLine(line)
+			if perr != nil {
+				return nil, fmt.Errorf("parse spans at offset %d error: %s", r.offset-int64(len(line)), perr.Error())
+			}
+			return spans, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReplayFile exports the spans stored in filename, gzip files are decompressed.
+func ReplayFile(ctx context.Context, filename string, exporter sdk.SpanExporter, opts ...ReplayOption) (ReplayStats, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return ReplayStats{}, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(filename, compressSuffix) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return ReplayStats{}, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return Replay(ctx, r, exporter, opts...)
+}
+
+// Replay exports the spans read from r with exporter.
+// The offset is only advanced once a batch is exported, so a resumed replay
+// exports every span at least once.
+func Replay(ctx context.Context, r io.Reader, exporter sdk.SpanExporter, opts ...ReplayOption) (ReplayStats, error) {
+	op := replayOptions{
+		batchSize: defaultReplayBatchSize,
+	}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	stats := ReplayStats{Offset: op.offset}
+	if op.offset > 0 {
+		if s, ok := r.(io.Seeker); ok {
+			if _, err := s.Seek(op.offset, io.SeekStart); err != nil {
+				return stats, err
+			}
+		} else if _, err := io.CopyN(io.Discard, r, op.offset); err != nil {
+			return stats, err
+		}
+	}
+
+	reader := NewSpanReader(r)
+	start := time.Now()
+	batch := make([]sdk.ReadOnlySpan, 0, op.batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := exporter.ExportSpans(ctx, batch); err != nil {
+			return err
+		}
+
+		stats.Spans += len(batch)
+		stats.Batches++
+		stats.Offset = op.offset + reader.Offset()
+		if op.progress != nil {
+			op.progress(stats.Offset)
+		}
+		batch = batch[:0]
+
+		if op.rate <= 0 {
+			return nil
+		}
+		// pace the replay so that stats.Spans are sent no faster than the rate.
+		wait := time.Duration(float64(stats.Spans)/float64(op.rate)*float64(time.Second)) - time.Since(start)
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		spans, err := reader.Next()
+		if err == io.EOF {
+			return stats, flush()
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		batch = append(batch, spans...)
+		limit := op.batchSize
+		if op.rate > 0 && op.rate < limit {
+			limit = op.rate
+		}
+		if len(batch) >= limit {
+			if err = flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+}
+
+func parseSpanLine(line []byte) ([]sdk.ReadOnlySpan, error) {
+	var data otlpTracesData
+	if err := json.Unmarshal(line, &data); err == nil && len(data.ResourceSpans) > 0 {
+		return spansFromOtlpTraces(data)
+	}
+
+	var s stdoutSpan
+	if err := json.Unmarshal(line, &s); err != nil {
+		return nil, err
+	}
+	stub, err := s.stub()
+	if err != nil {
+		return nil, err
+	}
+	if !stub.SpanContext.IsValid() {
+		return nil, errors.New("invalid span context")
+	}
+
+	return []sdk.ReadOnlySpan{stub.Snapshot()}, nil
+}
+
+func spansFromOtlpTraces(data otlpTracesData) ([]sdk.ReadOnlySpan, error) {
+	var out []sdk.ReadOnlySpan
+	for _, rs := range data.ResourceSpans {
+		attrs, err := attributesFromOtlp(rs.Resource.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		res := resource.NewWithAttributes(rs.SchemaUrl, attrs...)
+
+		for _, ss := range rs.ScopeSpans {
+			scope := instrumentation.Scope{Name: ss.Scope.Name, Version: ss.Scope.Version, SchemaURL: ss.SchemaUrl}
+			for _, s := range ss.Spans {
+				stub, err := s.stub()
+				if err != nil {
+					return nil, err
+				}
+				stub.Resource = res
+				stub.InstrumentationScope = scope
+				out = append(out, stub.Snapshot())
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (s otlpSpan) stub() (tracetest.SpanStub, error) {
+	var stub tracetest.SpanStub
+	sc, err := spanContextFromHex(s.TraceId, s.SpanId, s.TraceState, s.Flags)
+	if err != nil {
+		return stub, err
+	}
+	stub.SpanContext = sc
+	if len(s.ParentSpanId) > 0 {
+		if stub.Parent, err = spanContextFromHex(s.TraceId, s.ParentSpanId, "", s.Flags); err != nil {
+			return stub, err
+		}
+	}
+
+	stub.Name = s.Name
+	stub.SpanKind = trace.SpanKind(s.Kind)
+	if stub.StartTime, err = timeFromUnixNano(s.StartTimeUnixNano); err != nil {
+		return stub, err
+	}
+	if stub.EndTime, err = timeFromUnixNano(s.EndTimeUnixNano); err != nil {
+		return stub, err
+	}
+	if stub.Attributes, err = attributesFromOtlp(s.Attributes); err != nil {
+		return stub, err
+	}
+	stub.DroppedAttributes = int(s.DroppedAttributesCount)
+	stub.DroppedEvents = int(s.DroppedEventsCount)
+	stub.DroppedLinks = int(s.DroppedLinksCount)
+
+	for _, e := range s.Events {
+		ev := sdk.Event{Name: e.Name, DroppedAttributeCount: int(e.DroppedAttributesCount)}
+		if ev.Time, err = timeFromUnixNano(e.TimeUnixNano); err != nil {
+			return stub, err
+		}
+		if ev.Attributes, err = attributesFromOtlp(e.Attributes); err != nil {
+			return stub, err
+		}
+		stub.Events = append(stub.Events, ev)
+	}
+	for _, l := range s.Links {
+		link := sdk.Link{DroppedAttributeCount: int(l.DroppedAttributesCount)}
+		if link.SpanContext, err = spanContextFromHex(l.TraceId, l.SpanId, l.TraceState, l.Flags); err != nil {
+			return stub, err
+		}
+		if link.Attributes, err = attributesFromOtlp(l.Attributes); err != nil {
+			return stub, err
+		}
+		stub.Links = append(stub.Links, link)
+	}
+
+	stub.Status = sdk.Status{Description: s.Status.Message}
+	switch s.Status.Code {
+	case otlpStatusOk:
+		stub.Status.Code = codes.Ok
+	case otlpStatusError:
+		stub.Status.Code = codes.Error
+	default:
+		stub.Status.Code = codes.Unset
+	}
+
+	return stub, nil
+}
+
+func spanContextFromHex(traceID, spanID, traceState string, flags uint32) (trace.SpanContext, error) {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	ts, err := trace.ParseTraceState(traceState)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.TraceFlags(flags),
+		TraceState: ts,
+	}), nil
+}
+
+func timeFromUnixNano(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, n), nil
+}
+
+func attributesFromOtlp(kvs []otlpKeyValue) ([]attribute.KeyValue, error) {
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+
+	out := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		v, err := valueFromOtlp(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s error: %s", kv.Key, err.Error())
+		}
+		out = append(out, attribute.KeyValue{Key: attribute.Key(kv.Key), Value: v})
+	}
+
+	return out, nil
+}
+
+func valueFromOtlp(v otlpAnyValue) (attribute.Value, error) {
+	switch {
+	case v.StringValue != nil:
+		return attribute.StringValue(*v.StringValue), nil
+	case v.BoolValue != nil:
+		return attribute.BoolValue(*v.BoolValue), nil
+	case v.IntValue != nil:
+		i, err := strconv.ParseInt(*v.IntValue, 10, 64)
+		if err != nil {
+			return attribute.Value{}, err
+		}
+		return attribute.Int64Value(i), nil
+	case v.DoubleValue != nil:
+		return attribute.Float64Value(*v.DoubleValue), nil
+	case v.ArrayValue != nil:
+		return sliceFromOtlp(v.ArrayValue.Values)
+	default:
+		return attribute.StringValue(""), nil
+	}
+}
+
+// sliceFromOtlp infers the slice type from the first value.
+func sliceFromOtlp(values []otlpAnyValue) (attribute.Value, error) {
+	if len(values) == 0 {
+		return attribute.StringSliceValue(nil), nil
+	}
+
+	switch first := values[0]; {
+	case first.BoolValue != nil:
+		out := make([]bool, 0, len(values))
+		for _, v := range values {
+			out = append(out, v.BoolValue != nil && *v.BoolValue)
+		}
+		return attribute.BoolSliceValue(out), nil
+	case first.IntValue != nil:
+		out := make([]int64, 0, len(values))
+		for _, v := range values {
+			iv, err := valueFromOtlp(v)
+			if err != nil {
+				return attribute.Value{}, err
+			}
+			out = append(out, iv.AsInt64())
+		}
+		return attribute.Int64SliceValue(out), nil
+	case first.DoubleValue != nil:
+		out := make([]float64, 0, len(values))
+		for _, v := range values {
+			if v.DoubleValue != nil {
+				out = append(out, *v.DoubleValue)
+			}
+		}
+		return attribute.Float64SliceValue(out), nil
+	default:
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			sv, err := valueFromOtlp(v)
+			if err != nil {
+				return attribute.Value{}, err
+			}
+			out = append(out, sv.Emit())
+		}
+		return attribute.StringSliceValue(out), nil
+	}
+}
+
+// The types below decode the FileFormatStdout lines written by stdouttrace.
+type (
+	stdoutSpan struct {
+		Name                 string
+		SpanContext          stdoutSpanContext
+		Parent               stdoutSpanContext
+		SpanKind             int
+		StartTime            time.Time
+		EndTime              time.Time
+		Attributes           []stdoutKeyValue
+		Events               []stdoutEvent
+		Links                []stdoutLink
+		Status               stdoutStatus
+		DroppedAttributes    int
+		DroppedEvents        int
+		DroppedLinks         int
+		ChildSpanCount       int
+		Resource             []stdoutKeyValue
+		InstrumentationScope stdoutScope
+	}
+
+	stdoutSpanContext struct {
+		TraceID    string
+		SpanID     string
+		TraceFlags string
+		TraceState string
+		Remote     bool
+	}
+
+	stdoutKeyValue struct {
+		Key   string
+		Value struct {
+			Type  string
+			Value json.RawMessage
+		}
+	}
+
+	stdoutEvent struct {
+		Name                  string
+		Attributes            []stdoutKeyValue
+		DroppedAttributeCount int
+		Time                  time.Time
+	}
+
+	stdoutLink struct {
+		SpanContext           stdoutSpanContext
+		Attributes            []stdoutKeyValue
+		DroppedAttributeCount int
+	}
+
+	stdoutStatus struct {
+		Code        codes.Code
+		Description string
+	}
+
+	stdoutScope struct {
+		Name      string
+		Version   string
+		SchemaURL string
+	}
+)
+
+func (s stdoutSpan) stub() (tracetest.SpanStub, error) {
+	stub := tracetest.SpanStub{
+		Name:                 s.Name,
+		SpanKind:             trace.SpanKind(s.SpanKind),
+		StartTime:            s.StartTime,
+		EndTime:              s.EndTime,
+		Status:               sdk.Status{Code: s.Status.Code, Description: s.Status.Description},
+		DroppedAttributes:    s.DroppedAttributes,
+		DroppedEvents:        s.DroppedEvents,
+		DroppedLinks:         s.DroppedLinks,
+		ChildSpanCount:       s.ChildSpanCount,
+		InstrumentationScope: instrumentation.Scope{Name: s.InstrumentationScope.Name, Version: s.InstrumentationScope.Version, SchemaURL: s.InstrumentationScope.SchemaURL},
+	}
+
+	var err error
+	if stub.SpanContext, err = s.SpanContext.spanContext(); err != nil {
+		return stub, err
+	}
+	if stub.Parent, err = s.Parent.spanContext(); err != nil {
+		return stub, err
+	}
+	if stub.Attributes, err = attributesFromStdout(s.Attributes); err != nil {
+		return stub, err
+	}
+	attrs, err := attributesFromStdout(s.Resource)
+	if err != nil {
+		return stub, err
+	}
+	stub.Resource = resource.NewSchemaless(attrs...)
+
+	for _, e := range s.Events {
+		ev := sdk.Event{Name: e.Name, Time: e.Time, DroppedAttributeCount: e.DroppedAttributeCount}
+		if ev.Attributes, err = attributesFromStdout(e.Attributes); err != nil {
+			return stub, err
+		}
+		stub.Events = append(stub.Events, ev)
+	}
+	for _, l := range s.Links {
+		link := sdk.Link{DroppedAttributeCount: l.DroppedAttributeCount}
+		if link.SpanContext, err = l.SpanContext.spanContext(); err != nil {
+			return stub, err
+		}
+		if link.Attributes, err = attributesFromStdout(l.Attributes); err != nil {
+			return stub, err
+		}
+		stub.Links = append(stub.Links, link)
+	}
+
+	return stub, nil
+}
+
+func (c stdoutSpanContext) spanContext() (trace.SpanContext, error) {
+	// the zero parent is written as all zero ids.
+	if len(strings.Trim(c.SpanID, "0")) == 0 {
+		return trace.SpanContext{}, nil
+	}
+
+	flags, err := strconv.ParseUint(c.TraceFlags, 16, 8)
+	if err != nil && len(c.TraceFlags) > 0 {
+		return trace.SpanContext{}, err
+	}
+	sc, err := spanContextFromHex(c.TraceID, c.SpanID, c.TraceState, uint32(flags))
+	if err != nil {
+		return sc, err
+	}
+
+	return sc.WithRemote(c.Remote), nil
+}
+
+func attributesFromStdout(kvs []stdoutKeyValue) ([]attribute.KeyValue, error) {
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+
+	out := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		v, err := valueFromStdout(kv.Value.Type, kv.Value.Value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s error: %s", kv.Key, err.Error())
+		}
+		out = append(out, attribute.KeyValue{Key: attribute.Key(kv.Key), Value: v})
+	}
+
+	return out, nil
+}
+
+func valueFromStdout(typ string, raw json.RawMessage) (attribute.Value, error) {
+	var err error
+	switch typ {
+	case "BOOL":
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return attribute.BoolValue(v), err
+	case "INT64":
+		var v int64
+		err = json.Unmarshal(raw, &v)
+		return attribute.Int64Value(v), err
+	case "FLOAT64":
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return attribute.Float64Value(v), err
+	case "STRING":
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return attribute.StringValue(v), err
+	case "BOOLSLICE":
+		var v []bool
+		err = json.Unmarshal(raw, &v)
+		return attribute.BoolSliceValue(v), err
+	case "INT64SLICE":
+		var v []int64
+		err = json.Unmarshal(raw, &v)
+		return attribute.Int64SliceValue(v), err
+	case "FLOAT64SLICE":
+		var v []float64
+		err = json.Unmarshal(raw, &v)
+		return attribute.Float64SliceValue(v), err
+	case "STRINGSLICE":
+		var v []string
+		err = json.Unmarshal(raw, &v)
+		return attribute.StringSliceValue(v), err
+	default:
+		return attribute.Value{}, fmt.Errorf("unknown attribute type: %s", typ)
+	}
+}
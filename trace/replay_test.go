@@ -0,0 +1,73 @@
+package trace
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReplayFile(t *testing.T) {
+	for _, format := range []string{FileFormatStdout, FileFormatOtlpJson} {
+		t.Run(format, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "traces.json")
+			exp, err := NewFileExporter(filename, FileConfig{Format: format})
+			assert.Nil(t, err)
+			exportTestSpans(t, exp, "span1", "span2", "span3")
+
+			memory := tracetest.NewInMemoryExporter()
+			stats, err := ReplayFile(context.Background(), filename, memory, WithReplayBatchSize(2))
+			assert.Nil(t, err)
+			assert.Equal(t, 3, stats.Spans)
+			assert.Equal(t, 2, stats.Batches)
+
+			spans := memory.GetSpans()
+			assert.Equal(t, []string{"span1", "span2", "span3"}, spanNames(spans))
+			assert.True(t, spans[0].SpanContext.IsValid())
+			assert.Equal(t, codes.Error, spans[0].Status.Code)
+			assert.Contains(t, spans[0].Attributes, attribute.Int("count", 1))
+			assert.Contains(t, spans[0].Attributes, attribute.StringSlice("tags", []string{"a", "b"}))
+			assert.NotNil(t, spans[0].Resource)
+
+			// resume from the offset of the first batch.
+			var offsets []int64
+			_, err = ReplayFile(context.Background(), filename, tracetest.NewNoopExporter(),
+				WithReplayBatchSize(1), WithReplayProgress(func(offset int64) {
+					offsets = append(offsets, offset)
+				}))
+			assert.Nil(t, err)
+			assert.Len(t, offsets, 3)
+
+			memory.Reset()
+			stats, err = ReplayFile(context.Background(), filename, memory, WithReplayOffset(offsets[0]))
+			assert.Nil(t, err)
+			assert.Equal(t, 2, stats.Spans)
+			assert.Equal(t, offsets[2], stats.Offset)
+		})
+	}
+}
+
+func TestReplayRate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "traces.json")
+	exp, err := NewFileExporter(filename, FileConfig{})
+	assert.Nil(t, err)
+	exportTestSpans(t, exp, "span1", "span2", "span3")
+
+	stats, err := ReplayFile(context.Background(), filename, tracetest.NewNoopExporter(), WithReplayRate(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, stats.Spans)
+}
@@ -0,0 +1,266 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	// DetectorOS detects the os.type and os.description.
+	DetectorOS = "os"
+	// DetectorHost detects the host.name.
+	DetectorHost = "host"
+	// DetectorEnv reads the OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables.
+	DetectorEnv = "env"
+	// DetectorProcess detects the process attributes, including its command line.
+	DetectorProcess = "process"
+	// DetectorProcessRuntime detects the process pid, executable name and go runtime,
+	// without the command line and the executable path.
+	DetectorProcessRuntime = "processRuntime"
+	// DetectorTelemetrySDK detects the telemetry.sdk attributes.
+	DetectorTelemetrySDK = "telemetrySDK"
+	// DetectorContainer detects the container.id, see ContainerDetector.
+	DetectorContainer = "container"
+	// DetectorK8s detects the k8s pod and namespace, see K8sDetector.
+	DetectorK8s = "k8s"
+
+	defaultCgroupFile        = "/proc/self/cgroup"
+	defaultPodInfoDir        = "/etc/podinfo"
+	defaultK8sNamespaceFile  = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	k8sServiceHostEnv        = "KUBERNETES_SERVICE_HOST"
+	containerIdLength        = 64
+	containerIdAttributeName = "container.id"
+)
+
+// defaultDetectors are the detectors run when Config.Detectors is nil.
+var defaultDetectors = []string{
+	DetectorOS,
+	DetectorHost,
+	DetectorEnv,
+	DetectorProcess,
+	DetectorTelemetrySDK,
+}
+
+var builtinDetectors = map[string]func() []resource.Option{
+	DetectorOS: func() []resource.Option {
+		return []resource.Option{resource.WithOS()}
+	},
+	DetectorHost: func() []resource.Option {
+		return []resource.Option{resource.WithHost()}
+	},
+	DetectorEnv: func() []resource.Option {
+		return []resource.Option{resource.WithFromEnv()}
+	},
+	DetectorProcess: func() []resource.Option {
+		return []resource.Option{resource.WithProcess()}
+	},
+	DetectorProcessRuntime: func() []resource.Option {
+		return []resource.Option{
+			resource.WithProcessPID(),
+			resource.WithProcessExecutableName(),
+			resource.WithProcessRuntimeName(),
+			resource.WithProcessRuntimeVersion(),
+			resource.WithProcessRuntimeDescription(),
+		}
+	},
+	DetectorTelemetrySDK: func() []resource.Option {
+		return []resource.Option{resource.WithTelemetrySDK()}
+	},
+	DetectorContainer: func() []resource.Option {
+		return []resource.Option{resource.WithDetectors(ContainerDetector())}
+	},
+	DetectorK8s: func() []resource.Option {
+		return []resource.Option{resource.WithDetectors(K8sDetector())}
+	},
+}
+
+// Detectors returns the sorted names of the built-in detectors.
+func Detectors() []string {
+	names := make([]string, 0, len(builtinDetectors))
+	for name := range builtinDetectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// newResource runs the configured detectors, the attributes set by options
+// take precedence over the detected ones. Unknown detectors are an error.
+func newResource(ctx context.Context, c *Config) (*resource.Resource, error) {
+	names := c.Detectors
+	if names == nil {
+		names = defaultDetectors
+	}
+
+	var opts []resource.Option
+	if len(c.InstanceId) == 0 {
+		// the random id comes first, so that a detected one takes precedence.
+		opts = append(opts, resource.WithAttributes(attribute.Key("service.instance.id").String(uuid.NewString())))
+	}
+	for _, name := range names {
+		detector, ok := builtinDetectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown detector %q, available: %s", name, strings.Join(Detectors(), ", "))
+		}
+		opts = append(opts, detector()...)
+	}
+	if len(c.ResourceDetectors) > 0 {
+		opts = append(opts, resource.WithDetectors(c.ResourceDetectors...))
+	}
+
+	attrs := c.identityAttributes()
+	attrs = append(attrs, c.Attributes...)
+	opts = append(opts, resource.WithAttributes(attrs...))
+
+	return resource.New(ctx, opts...)
+}
+
+// identityAttributes returns the service identity attributes.
+func (c *Config) identityAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	add := func(key, value string) {
+		if len(value) > 0 {
+			attrs = append(attrs, attribute.Key(key).String(value))
+		}
+	}
+	add("service.instance.id", c.InstanceId)
+	add("service.name", c.Name)
+	add("service.version", c.Version)
+	add("service.namespace", c.Namespace)
+	add("deployment.environment", c.Environment)
+
+	return attrs
+}
+
+// containerDetector reads the container id from the cgroup file.
+type containerDetector struct {
+	cgroupFile string
+}
+
+// ContainerDetector returns a resource.Detector which sets container.id from
+// /proc/self/cgroup, it detects nothing outside of a container.
+func ContainerDetector() resource.Detector {
+	return &containerDetector{cgroupFile: defaultCgroupFile}
+}
+
+// containerIdPattern matches the id of docker, containerd and cri-o cgroup paths,
+// like /docker/<id>, /kubepods/.../<id> or /system.slice/cri-containerd-<id>.scope.
+var containerIdPattern = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?$`)
+
+// Detect returns a resource with the container.id.
+func (d *containerDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	f, err := os.Open(d.cgroupFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return resource.Empty(), nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if m := containerIdPattern.FindStringSubmatch(parts[2]); len(m) == 2 && len(m[1]) == containerIdLength {
+			return resource.NewSchemaless(attribute.String(containerIdAttributeName, m[1])), nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return resource.Empty(), nil
+}
+
+// k8sDetector reads the pod identity exposed by the downward API.
+type k8sDetector struct {
+	getenv        func(string) string
+	podInfoDir    string
+	namespaceFile string
+}
+
+// K8sDetector returns a resource.Detector which sets the k8s.pod.name,
+// k8s.pod.uid, k8s.namespace.name and k8s.node.name attributes.
+//
+// The values are read from the downward API environment variables
+// K8S_POD_NAME, K8S_POD_UID, K8S_NAMESPACE and K8S_NODE_NAME (the POD_NAME,
+// POD_UID, POD_NAMESPACE and NODE_NAME variants are accepted too), then from
+// the name, uid and namespace files of a downward API volume mounted at
+// /etc/podinfo. The namespace falls back to the service account namespace,
+// and the pod name to the hostname. It detects nothing outside of Kubernetes.
+func K8sDetector() resource.Detector {
+	return &k8sDetector{
+		getenv:        os.Getenv,
+		podInfoDir:    defaultPodInfoDir,
+		namespaceFile: defaultK8sNamespaceFile,
+	}
+}
+
+// Detect returns a resource with the k8s attributes.
+func (d *k8sDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	namespace := d.lookup("namespace", "K8S_NAMESPACE", "POD_NAMESPACE")
+	if len(namespace) == 0 {
+		namespace = readTrimmed(d.namespaceFile)
+	}
+	inCluster := len(namespace) > 0 || len(d.getenv(k8sServiceHostEnv)) > 0
+	if !inCluster {
+		return resource.Empty(), nil
+	}
+
+	podName := d.lookup("name", "K8S_POD_NAME", "POD_NAME")
+	if len(podName) == 0 {
+		podName, _ = os.Hostname()
+	}
+
+	var attrs []attribute.KeyValue
+	add := func(key, value string) {
+		if len(value) > 0 {
+			attrs = append(attrs, attribute.String(key, value))
+		}
+	}
+	add("k8s.pod.name", podName)
+	add("k8s.pod.uid", d.lookup("uid", "K8S_POD_UID", "POD_UID"))
+	add("k8s.namespace.name", namespace)
+	add("k8s.node.name", d.lookup("", "K8S_NODE_NAME", "NODE_NAME"))
+
+	return resource.NewSchemaless(attrs...), nil
+}
+
+// lookup returns the first non-empty environment variable of envs, or the
+// content of the file of the downward API volume.
+func (d *k8sDetector) lookup(file string, envs ...string) string {
+	for _, env := range envs {
+		if v := strings.TrimSpace(d.getenv(env)); len(v) > 0 {
+			return v
+		}
+	}
+	if len(file) == 0 {
+		return ""
+	}
+
+	return readTrimmed(filepath.Join(d.podInfoDir, file))
+}
+
+func readTrimmed(filename string) string {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
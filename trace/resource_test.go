@@ -0,0 +1,192 @@
+package trace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const testContainerId = "3c8a4c3ea4e1a5d3f08f3cd0e5e3a4b7a9c3b2e1f0d9c8b7a6f5e4d3c2b1a0f9"
+
+func resourceAttributes(t *testing.T, opts ...Option) map[attribute.Key]attribute.Value {
+	recorder := tracetest.NewSpanRecorder()
+	opts = append([]Option{WithBatcher(KindNoop), WithSpanProcessor(recorder)}, opts...)
+	tracing, err := New(opts...)
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	_, span := tracing.provider.Tracer("test").Start(context.Background(), "resource")
+	span.End()
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Resource().Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestDefaultDetectors(t *testing.T) {
+	attrs := resourceAttributes(t, WithName("svc"))
+	assert.Equal(t, "svc", attrs["service.name"].AsString())
+	assert.Contains(t, attrs, attribute.Key("host.name"))
+	assert.Contains(t, attrs, attribute.Key("process.pid"))
+	assert.Contains(t, attrs, attribute.Key("telemetry.sdk.name"))
+}
+
+func TestSelectedDetectors(t *testing.T) {
+	attrs := resourceAttributes(t, WithDetectors(DetectorProcessRuntime))
+	assert.Contains(t, attrs, attribute.Key("process.pid"))
+	assert.Contains(t, attrs, attribute.Key("process.runtime.name"))
+	assert.NotContains(t, attrs, attribute.Key("process.command_args"))
+	assert.NotContains(t, attrs, attribute.Key("host.name"))
+
+	custom := resource.StringDetector("", "custom.key", func() (string, error) {
+		return "custom", nil
+	})
+	attrs = resourceAttributes(t, WithDetectors(), WithResourceDetectors(custom))
+	assert.Equal(t, "custom", attrs["custom.key"].AsString())
+	assert.NotContains(t, attrs, attribute.Key("telemetry.sdk.name"))
+}
+
+func TestValidateDetectors(t *testing.T) {
+	c := &Config{Sampler: 1, Batcher: KindNoop, Detectors: []string{DetectorK8s, "unknown"}}
+	errs, ok := c.Validate().(ValidationErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "detectors", errs[0].Field)
+
+	_, err := newResource(context.Background(), c)
+	assert.NotNil(t, err)
+}
+
+func TestContainerDetector(t *testing.T) {
+	tests := []struct {
+		name   string
+		cgroup string
+		id     string
+	}{
+		{
+			name:   "docker",
+			cgroup: "12:pids:/docker/" + testContainerId + "\n11:memory:/docker/" + testContainerId + "\n",
+			id:     testContainerId,
+		},
+		{
+			name:   "kubepods",
+			cgroup: "1:name=systemd:/kubepods/besteffort/pod0c6d7a1e/" + testContainerId + "\n",
+			id:     testContainerId,
+		},
+		{
+			name:   "systemd scope",
+			cgroup: "0::/system.slice/cri-containerd-" + testContainerId + ".scope\n",
+			id:     testContainerId,
+		},
+		{
+			name:   "host",
+			cgroup: "0::/user.slice/user-1000.slice/session-2.scope\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "cgroup")
+			assert.Nil(t, os.WriteFile(filename, []byte(tt.cgroup), 0644))
+
+			r, err := (&containerDetector{cgroupFile: filename}).Detect(context.Background())
+			assert.Nil(t, err)
+			v, _ := r.Set().Value(containerIdAttributeName)
+			assert.Equal(t, tt.id, v.AsString())
+		})
+	}
+
+	r, err := (&containerDetector{cgroupFile: filepath.Join(t.TempDir(), "missing")}).Detect(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestK8sDetector(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{}
+	d := &k8sDetector{
+		getenv:        func(key string) string { return env[key] },
+		podInfoDir:    filepath.Join(dir, "podinfo"),
+		namespaceFile: filepath.Join(dir, "namespace"),
+	}
+
+	r, err := d.Detect(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, r.Len())
+
+	env["K8S_POD_NAME"] = "api-7d9f"
+	env["POD_NAMESPACE"] = "prod"
+	env["NODE_NAME"] = "node-1"
+	r, err = d.Detect(context.Background())
+	assert.Nil(t, err)
+	set := r.Set()
+	for key, want := range map[attribute.Key]string{
+		"k8s.pod.name":       "api-7d9f",
+		"k8s.namespace.name": "prod",
+		"k8s.node.name":      "node-1",
+	} {
+		v, ok := set.Value(key)
+		assert.True(t, ok)
+		assert.Equal(t, want, v.AsString())
+	}
+	assert.False(t, set.HasValue("k8s.pod.uid"))
+
+	// the downward API volume and the service account namespace.
+	env = map[string]string{}
+	assert.Nil(t, os.MkdirAll(d.podInfoDir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(d.podInfoDir, "name"), []byte("worker-0\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(d.podInfoDir, "uid"), []byte("0c6d7a1e"), 0644))
+	assert.Nil(t, os.WriteFile(d.namespaceFile, []byte("batch"), 0644))
+	r, err = d.Detect(context.Background())
+	assert.Nil(t, err)
+	set = r.Set()
+	v, _ := set.Value("k8s.pod.name")
+	assert.Equal(t, "worker-0", v.AsString())
+	v, _ = set.Value("k8s.pod.uid")
+	assert.Equal(t, "0c6d7a1e", v.AsString())
+	v, _ = set.Value("k8s.namespace.name")
+	assert.Equal(t, "batch", v.AsString())
+}
+
+func TestServiceIdentity(t *testing.T) {
+	attrs := resourceAttributes(t,
+		WithDetectors(),
+		WithName("checkout"),
+		WithAttributes(attribute.String("team", "payments")),
+		WithVersion("1.4.2"),
+		WithNamespace("shop"),
+		WithEnvironment("staging"),
+		WithAttributes(attribute.String("region", "eu")),
+	)
+	assert.Equal(t, "checkout", attrs["service.name"].AsString())
+	assert.Equal(t, "1.4.2", attrs["service.version"].AsString())
+	assert.Equal(t, "shop", attrs["service.namespace"].AsString())
+	assert.Equal(t, "staging", attrs["deployment.environment"].AsString())
+	assert.Equal(t, "payments", attrs["team"].AsString())
+	assert.Equal(t, "eu", attrs["region"].AsString())
+	_, err := uuid.Parse(attrs["service.instance.id"].AsString())
+	assert.Nil(t, err)
+
+	attrs = resourceAttributes(t, WithDetectors(), WithInstanceId("checkout-0"))
+	assert.Equal(t, "checkout-0", attrs["service.instance.id"].AsString())
+}
+
+func TestDetectedInstanceId(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.instance.id=pod-1")
+	attrs := resourceAttributes(t, WithDetectors(DetectorEnv))
+	assert.Equal(t, "pod-1", attrs["service.instance.id"].AsString())
+
+	attrs = resourceAttributes(t, WithDetectors(DetectorEnv), WithInstanceId("checkout-0"))
+	assert.Equal(t, "checkout-0", attrs["service.instance.id"].AsString())
+}
@@ -0,0 +1,245 @@
+package trace
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000000000"
+	compressSuffix   = ".gz"
+)
+
+// rotateWriter is an io.WriteCloser writing to a file which is rotated by size and age.
+type rotateWriter struct {
+	filename   string
+	perm       os.FileMode
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	compress   bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openTime time.Time
+
+	// cleanupMu serializes compressions and removals of backups.
+	cleanupMu sync.Mutex
+	wg        sync.WaitGroup
+}
+
+var _ io.WriteCloser = (*rotateWriter)(nil)
+
+func newRotateWriter(filename string, c FileConfig) (*rotateWriter, error) {
+	w := &rotateWriter{
+		filename:   filename,
+		perm:       c.Perm,
+		maxSize:    c.MaxSize,
+		interval:   c.RotateInterval,
+		maxBackups: c.MaxBackups,
+		compress:   c.Compress,
+	}
+	if w.perm == 0 {
+		w.perm = defaultFilePerm
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes p to the current file, rotating it first if needed.
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one.
+func (w *rotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Close closes the current file and waits for the pending compressions.
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+func (w *rotateWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+n > w.maxSize {
+		return true
+	}
+
+	return w.interval > 0 && time.Since(w.openTime) >= w.interval
+}
+
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return fmt.Errorf("file exporter endpoint error: %s", err.Error())
+	}
+
+	f, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, w.perm)
+	if err != nil {
+		return fmt.Errorf("file exporter endpoint error: %s", err.Error())
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("file exporter endpoint error: %s", err.Error())
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openTime = time.Now()
+	return nil
+}
+
+func (w *rotateWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	backup := w.backupName(time.Now().UTC())
+	if err := os.Rename(w.filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.cleanup(backup)
+	}()
+	return nil
+}
+
+func (w *rotateWriter) backupName(t time.Time) string {
+	dir := filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, t.Format(backupTimeFormat), ext))
+}
+
+// backups returns the rotated files, oldest first.
+func (w *rotateWriter) backups() ([]string, error) {
+	dir := filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], compressSuffix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (w *rotateWriter) cleanup(backup string) {
+	w.cleanupMu.Lock()
+	defer w.cleanupMu.Unlock()
+
+	if w.compress {
+		if err := compressFile(backup, w.perm); err != nil {
+			log.Printf("[otel] compress %s error: %v", backup, err)
+		}
+	}
+	if w.maxBackups <= 0 {
+		return
+	}
+
+	files, err := w.backups()
+	if err != nil {
+		log.Printf("[otel] list backups error: %v", err)
+		return
+	}
+	for i := 0; i < len(files)-w.maxBackups; i++ {
+		if err := os.Remove(files[i]); err != nil && !os.IsNotExist(err) {
+			log.Printf("[otel] remove %s error: %v", files[i], err)
+		}
+	}
+}
+
+func compressFile(name string, perm os.FileMode) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
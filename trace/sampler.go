@@ -0,0 +1,250 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// A SamplingRule overrides the ratio of the root spans it matches.
+	SamplingRule struct {
+		// SpanName is a regular expression matched against the span name, empty matches all.
+		SpanName string `json:"spanName,omitempty" yaml:"spanName,omitempty"`
+		// Kind is the span kind, like server or client, empty matches all.
+		Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+		// Ratio is the sampling ratio of the matched spans.
+		Ratio float64 `json:"ratio" yaml:"ratio"`
+	}
+
+	// A SamplerConfig is the config swapped by Tracing.UpdateSampler.
+	SamplerConfig struct {
+		// Ratio is the sampling ratio of the root spans matching no rule, it is
+		// required so that a file missing it does not disable the sampling.
+		Ratio *float64 `json:"ratio" yaml:"ratio"`
+		// Rules are evaluated in order, the first matching rule applies.
+		Rules []SamplingRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+	}
+
+	// DynamicSampler is a sdk.Sampler whose ratio and rules can be swapped
+	// atomically while spans are started.
+	DynamicSampler struct {
+		state atomic.Value
+	}
+
+	samplerState struct {
+		config   SamplerConfig
+		fallback sdk.Sampler
+		rules    []compiledRule
+	}
+
+	compiledRule struct {
+		name    *regexp.Regexp
+		kind    trace.SpanKind
+		sampler sdk.Sampler
+	}
+)
+
+// defaultWatchInterval is the polling interval of Watch when none is given.
+const defaultWatchInterval = 10 * time.Second
+
+var _ sdk.Sampler = (*DynamicSampler)(nil)
+
+// NewDynamicSampler returns a DynamicSampler using c.
+func NewDynamicSampler(c SamplerConfig) (*DynamicSampler, error) {
+	s := &DynamicSampler{}
+	if err := s.Update(c); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Update atomically replaces the ratio and rules of the sampler.
+func (s *DynamicSampler) Update(c SamplerConfig) error {
+	state, err := newSamplerState(c)
+	if err != nil {
+		return err
+	}
+
+	s.state.Store(state)
+	return nil
+}
+
+// Config returns the config in use.
+func (s *DynamicSampler) Config() SamplerConfig {
+	return s.load().config
+}
+
+// ShouldSample applies the first matching rule, or the ratio.
+func (s *DynamicSampler) ShouldSample(p sdk.SamplingParameters) sdk.SamplingResult {
+	state := s.load()
+	for _, rule := range state.rules {
+		if rule.match(p) {
+			return rule.sampler.ShouldSample(p)
+		}
+	}
+
+	return state.fallback.ShouldSample(p)
+}
+
+// Description returns the description of the sampler.
+func (s *DynamicSampler) Description() string {
+	c := s.load().config
+	return fmt.Sprintf("DynamicSampler{ratio:%g,rules:%d}", *c.Ratio, len(c.Rules))
+}
+
+func (s *DynamicSampler) load() *samplerState {
+	return s.state.Load().(*samplerState)
+}
+
+func newSamplerState(c SamplerConfig) (*samplerState, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	// the caller must not change the ratio of the state.
+	ratio := *c.Ratio
+	c.Ratio = &ratio
+	state := &samplerState{
+		config:   c,
+		fallback: sdk.TraceIDRatioBased(*c.Ratio),
+	}
+	for _, r := range c.Rules {
+		rule := compiledRule{sampler: sdk.TraceIDRatioBased(r.Ratio)}
+		if len(r.SpanName) > 0 {
+			rule.name = regexp.MustCompile(r.SpanName)
+		}
+		if len(r.Kind) > 0 {
+			rule.kind = spanKinds[r.Kind]
+		}
+		state.rules = append(state.rules, rule)
+	}
+
+	return state, nil
+}
+
+var spanKinds = map[string]trace.SpanKind{
+	"internal": trace.SpanKindInternal,
+	"server":   trace.SpanKindServer,
+	"client":   trace.SpanKindClient,
+	"producer": trace.SpanKindProducer,
+	"consumer": trace.SpanKindConsumer,
+}
+
+func (c SamplerConfig) validate() error {
+	var errs ValidationErrors
+	if c.Ratio == nil {
+		errs = append(errs, FieldError{Field: "ratio", Message: "is required"})
+	} else if *c.Ratio < 0 || *c.Ratio > 1 {
+		errs = append(errs, FieldError{Field: "ratio", Message: fmt.Sprintf("must be in [0, 1], got %v", *c.Ratio)})
+	}
+	errs = append(errs, validateSamplingRules(c.Rules)...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateSamplingRules returns the errors of rules, their fields are named rules[i].
+func validateSamplingRules(rules []SamplingRule) ValidationErrors {
+	var errs ValidationErrors
+	for i, r := range rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if r.Ratio < 0 || r.Ratio > 1 {
+			errs = append(errs, FieldError{Field: field + ".ratio", Message: fmt.Sprintf("must be in [0, 1], got %v", r.Ratio)})
+		}
+		if _, err := regexp.Compile(r.SpanName); err != nil {
+			errs = append(errs, FieldError{Field: field + ".spanName", Message: err.Error()})
+		}
+		if _, ok := spanKinds[r.Kind]; len(r.Kind) > 0 && !ok {
+			errs = append(errs, FieldError{Field: field + ".kind", Message: fmt.Sprintf("unknown span kind %q", r.Kind)})
+		}
+	}
+
+	return errs
+}
+
+func (r compiledRule) match(p sdk.SamplingParameters) bool {
+	if r.name != nil && !r.name.MatchString(p.Name) {
+		return false
+	}
+	if r.kind != trace.SpanKindUnspecified && r.kind != p.Kind {
+		return false
+	}
+
+	return true
+}
+
+// LoadSamplerConfigFile reads a YAML or JSON SamplerConfig from filename.
+func LoadSamplerConfigFile(filename string) (SamplerConfig, error) {
+	var c SamplerConfig
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return c, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err = dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("decode sampler config error: %s", err.Error())
+	}
+
+	return c, c.validate()
+}
+
+// Watch polls filename every interval and updates the sampler when the file
+// changes, until ctx is done. Invalid files are logged and ignored.
+// A non-positive interval defaults to 10 seconds.
+func (s *DynamicSampler) Watch(ctx context.Context, filename string, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	var lastMod time.Time
+	var lastSize int64
+	reload := func() {
+		info, err := os.Stat(filename)
+		if err != nil {
+			log.Printf("[otel] watch sampler file error: %v", err)
+			return
+		}
+		if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+			return
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+
+		c, err := LoadSamplerConfigFile(filename)
+		if err != nil {
+			log.Printf("[otel] load sampler file error: %v", err)
+			return
+		}
+		if err = s.Update(c); err != nil {
+			log.Printf("[otel] update sampler error: %v", err)
+		}
+	}
+
+	reload()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reload()
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package trace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func ratioOf(ratio float64) *float64 {
+	return &ratio
+}
+
+func TestDynamicSamplerRules(t *testing.T) {
+	tracing, err := New(WithBatcher(KindNoop), WithSampler(0), WithSamplingRules(
+		SamplingRule{SpanName: "^/health", Ratio: 0},
+		SamplingRule{Kind: "server", Ratio: 1},
+	))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	tracer := tracing.provider.Tracer("test")
+	_, span := tracer.Start(context.Background(), "/orders", trace.WithSpanKind(trace.SpanKindServer))
+	assert.True(t, span.SpanContext().IsSampled())
+	_, span = tracer.Start(context.Background(), "/health", trace.WithSpanKind(trace.SpanKindServer))
+	assert.False(t, span.SpanContext().IsSampled())
+	_, span = tracer.Start(context.Background(), "query", trace.WithSpanKind(trace.SpanKindClient))
+	assert.False(t, span.SpanContext().IsSampled())
+}
+
+func TestDynamicSamplerInvalid(t *testing.T) {
+	_, err := NewDynamicSampler(SamplerConfig{
+		Ratio: ratioOf(2),
+		Rules: []SamplingRule{{SpanName: "(", Kind: "unknown", Ratio: -1}},
+	})
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ratio", "rules[0].ratio", "rules[0].spanName", "rules[0].kind"}, []string{
+		errs[0].Field, errs[1].Field, errs[2].Field, errs[3].Field,
+	})
+
+	c := &Config{Sampler: 1, Batcher: KindNoop, SamplingRules: []SamplingRule{{Kind: "unknown", Ratio: 1}}}
+	errs, ok = c.Validate().(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, "samplingRules[0].kind", errs[0].Field)
+}
+
+func TestTracingUpdateSampler(t *testing.T) {
+	tracing, err := New(WithBatcher(KindNoop), WithSampler(0))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	tracer := tracing.provider.Tracer("test")
+	_, span := tracer.Start(context.Background(), "before")
+	assert.False(t, span.SpanContext().IsSampled())
+
+	assert.NotNil(t, tracing.UpdateSampler(SamplerConfig{Ratio: ratioOf(-1)}))
+	assert.Nil(t, tracing.UpdateSampler(SamplerConfig{Ratio: ratioOf(1)}))
+	assert.Equal(t, 1.0, *tracing.SamplerConfig().Ratio)
+	_, span = tracer.Start(context.Background(), "after")
+	assert.True(t, span.SpanContext().IsSampled())
+}
+
+func TestTracingUpdateSamplerConcurrent(t *testing.T) {
+	tracing, err := New(WithBatcher(KindNoop))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	tracer := tracing.provider.Tracer("test")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_, span := tracer.Start(context.Background(), "concurrent")
+				span.End()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, tracing.UpdateSampler(SamplerConfig{
+			Ratio: ratioOf(float64(i%2) * 0.5),
+			Rules: []SamplingRule{{SpanName: "concurrent", Ratio: float64(i % 2)}},
+		}))
+	}
+	wg.Wait()
+}
+
+func TestTracingWatchSamplerFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sampler.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 0\n"), 0644))
+
+	tracing, err := New(WithBatcher(KindNoop))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	tracing.WatchSamplerFile(filename, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return *tracing.SamplerConfig().Ratio == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 0.25\nrules:\n  - spanName: ^/api\n    ratio: 1\n"), 0644))
+	assert.Eventually(t, func() bool {
+		c := tracing.SamplerConfig()
+		return *c.Ratio == 0.25 && len(c.Rules) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// an invalid file keeps the previous config.
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 3\n"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0.25, *tracing.SamplerConfig().Ratio)
+
+	// so does a file missing the ratio, which would disable the sampling.
+	assert.Nil(t, os.WriteFile(filename, []byte("rules:\n  - spanName: ^/api\n    ratio: 1\n"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0.25, *tracing.SamplerConfig().Ratio)
+}
+
+func TestWatchSamplerFileDefaultInterval(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sampler.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 0.5\n"), 0644))
+
+	tracing, err := New(WithBatcher(KindNoop))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	// the file is read once before polling, which must not panic.
+	tracing.WatchSamplerFile(filename, 0)
+	assert.Eventually(t, func() bool {
+		return *tracing.SamplerConfig().Ratio == 0.5
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoadSamplerConfigFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sampler.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 0.5\nrules:\n  - spanName: ^/api\n    ratio: 1\n"), 0644))
+	c, err := LoadSamplerConfigFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, *c.Ratio)
+	assert.Len(t, c.Rules, 1)
+
+	// a misspelled field is an error, not a silently ignored rule.
+	assert.Nil(t, os.WriteFile(filename, []byte("ratio: 0.5\nrule:\n  - ratio: 1\n"), 0644))
+	_, err = LoadSamplerConfigFile(filename)
+	assert.NotNil(t, err)
+
+	// the ratio is required.
+	assert.Nil(t, os.WriteFile(filename, []byte("rules:\n  - ratio: 1\n"), 0644))
+	_, err = LoadSamplerConfigFile(filename)
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, "ratio", errs[0].Field)
+	_, err = NewDynamicSampler(SamplerConfig{})
+	assert.NotNil(t, err)
+}
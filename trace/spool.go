@@ -0,0 +1,400 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultSpoolMaxBytes      = 100 << 20
+	defaultSpoolRetryInterval = 5 * time.Second
+	defaultSpoolMaxAttempts   = 5
+	spoolSuffix               = ".wal"
+)
+
+type (
+	// A SpoolConfig is the config of the disk spool of failed span batches.
+	SpoolConfig struct {
+		// Dir is the directory storing the failed batches.
+		Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+		// MaxBytes bounds the disk usage, the oldest batches are evicted first.
+		// Defaults to 100MB.
+		MaxBytes int64 `json:"maxBytes,omitempty" yaml:"maxBytes,omitempty"`
+		// RetryInterval is the interval between replays of the stored batches.
+		// Defaults to 5s.
+		RetryInterval time.Duration `json:"retryInterval,omitempty" yaml:"retryInterval,omitempty"`
+		// MaxAttempts is the number of failed replays after which a stored
+		// batch is dropped, if the wrapped exporter exported other batches
+		// meanwhile. Such a batch is rejected by the collector, like with a
+		// HTTP 400, and would block the later batches. Defaults to 5.
+		MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	}
+
+	// SpoolStats is a snapshot of the spool counters.
+	SpoolStats struct {
+		// QueuedBatches is the number of batches stored on disk.
+		QueuedBatches int
+		// QueuedBytes is the disk usage of the stored batches.
+		QueuedBytes int64
+		// SpooledBatches is the number of batches stored after a failed export.
+		SpooledBatches uint64
+		// ReplayedBatches is the number of stored batches exported successfully.
+		ReplayedBatches uint64
+		// EvictedBatches is the number of stored batches removed to respect MaxBytes.
+		EvictedBatches uint64
+		// DroppedBatches is the number of batches lost because they could not be stored.
+		DroppedBatches uint64
+		// RejectedBatches is the number of stored batches dropped after MaxAttempts
+		// failed replays, while other batches were exported.
+		RejectedBatches uint64
+	}
+
+	// SpoolExporter is a sdk.SpanExporter which stores the batches failed to
+	// export in a bounded on-disk write-ahead log, and replays them in order
+	// once the wrapped exporter recovers.
+	//
+	// The stored batches are replayed before a new batch is exported, so the
+	// order of the batches is kept, except behind a stored batch which still
+	// fails while the new ones are exported, like a batch rejected by the
+	// collector, until it is dropped after MaxAttempts.
+	SpoolExporter struct {
+		exporter sdk.SpanExporter
+		dir      string
+		maxBytes int64
+		interval time.Duration
+		attempts int
+
+		mu      sync.Mutex
+		segs    []spoolSegment
+		bytes   int64
+		nextSeq uint64
+
+		// replayMu serializes the replays.
+		replayMu sync.Mutex
+
+		spooled  uint64
+		replayed uint64
+		evicted  uint64
+		dropped  uint64
+		rejected uint64
+		// exported counts the successful exports of the wrapped exporter, it
+		// tells a rejected batch from an unavailable exporter.
+		exported uint64
+
+		done     chan struct{}
+		stopOnce sync.Once
+		wg       sync.WaitGroup
+	}
+
+	spoolSegment struct {
+		seq  uint64
+		size int64
+		// attempts is the number of failed replays, and exported the value of
+		// SpoolExporter.exported at the first one.
+		attempts int
+		exported uint64
+	}
+)
+
+var _ sdk.SpanExporter = (*SpoolExporter)(nil)
+
+// NewSpoolExporter returns a SpoolExporter wrapping exporter, the batches left
+// in c.Dir by a previous process are replayed as well.
+func NewSpoolExporter(exporter sdk.SpanExporter, c SpoolConfig) (*SpoolExporter, error) {
+	if len(c.Dir) == 0 {
+		return nil, fmt.Errorf("spool dir is empty")
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultSpoolMaxBytes
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaultSpoolRetryInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultSpoolMaxAttempts
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("spool dir error: %s", err.Error())
+	}
+
+	e := &SpoolExporter{
+		exporter: exporter,
+		dir:      c.Dir,
+		maxBytes: c.MaxBytes,
+		interval: c.RetryInterval,
+		attempts: c.MaxAttempts,
+		done:     make(chan struct{}),
+	}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// ExportSpans replays the stored batches, then exports spans with the wrapped
+// exporter, spans failed to export are stored on disk and nil is returned.
+func (e *SpoolExporter) ExportSpans(ctx context.Context, spans []sdk.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	if e.queued() > 0 {
+		// the failure is retried by the next replay.
+		_ = e.Replay(ctx)
+	}
+	err := e.exporter.ExportSpans(ctx, spans)
+	if err == nil {
+		atomic.AddUint64(&e.exported, 1)
+		return nil
+	}
+
+	if serr := e.spool(spans); serr != nil {
+		atomic.AddUint64(&e.dropped, 1)
+		log.Printf("[otel] spool error: %v", serr)
+		return err
+	}
+
+	atomic.AddUint64(&e.spooled, 1)
+	return nil
+}
+
+// Shutdown stops the replays and shuts down the wrapped exporter,
+// the stored batches are kept for the next process.
+func (e *SpoolExporter) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() {
+		close(e.done)
+	})
+	e.wg.Wait()
+	return e.exporter.Shutdown(ctx)
+}
+
+// Stats returns the current counters of the spool.
+func (e *SpoolExporter) Stats() SpoolStats {
+	e.mu.Lock()
+	queued, size := len(e.segs), e.bytes
+	e.mu.Unlock()
+
+	return SpoolStats{
+		QueuedBatches:   queued,
+		QueuedBytes:     size,
+		SpooledBatches:  atomic.LoadUint64(&e.spooled),
+		ReplayedBatches: atomic.LoadUint64(&e.replayed),
+		EvictedBatches:  atomic.LoadUint64(&e.evicted),
+		DroppedBatches:  atomic.LoadUint64(&e.dropped),
+		RejectedBatches: atomic.LoadUint64(&e.rejected),
+	}
+}
+
+func (e *SpoolExporter) queued() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.segs)
+}
+
+// Replay exports the stored batches oldest first, it stops at the first failure,
+// unless the batch failed MaxAttempts times while other batches were exported,
+// then it is dropped as rejected.
+func (e *SpoolExporter) Replay(ctx context.Context) error {
+	e.replayMu.Lock()
+	defer e.replayMu.Unlock()
+
+	for {
+		e.mu.Lock()
+		if len(e.segs) == 0 {
+			e.mu.Unlock()
+			return nil
+		}
+		seg := e.segs[0]
+		e.mu.Unlock()
+
+		name := e.segmentName(seg.seq)
+		b, err := os.ReadFile(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// evicted meanwhile.
+				e.removeSegment(seg.seq, false)
+				continue
+			}
+			return err
+		}
+
+		spans, err := parseSpanLine(b)
+		if err != nil {
+			log.Printf("[otel] spool corrupted batch %s: %v", name, err)
+			e.removeSegment(seg.seq, true)
+			continue
+		}
+		if err = e.exporter.ExportSpans(ctx, spans); err != nil {
+			if !e.failSegment(seg.seq) {
+				return err
+			}
+
+			log.Printf("[otel] spool rejected batch %s after %d attempts: %v", name, e.attempts, err)
+			e.removeSegment(seg.seq, true)
+			atomic.AddUint64(&e.rejected, 1)
+			continue
+		}
+
+		atomic.AddUint64(&e.exported, 1)
+		e.removeSegment(seg.seq, true)
+		atomic.AddUint64(&e.replayed, 1)
+	}
+}
+
+func (e *SpoolExporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+			_ = e.Replay(ctx)
+			cancel()
+		}
+	}
+}
+
+func (e *SpoolExporter) spool(spans []sdk.ReadOnlySpan) error {
+	b, err := json.Marshal(otlpTracesFromSpans(spans))
+	if err != nil {
+		return err
+	}
+	size := int64(len(b))
+	if size > e.maxBytes {
+		return fmt.Errorf("batch of %d bytes exceeds the spool limit", size)
+	}
+
+	e.mu.Lock()
+	seq := e.nextSeq
+	e.nextSeq++
+	e.mu.Unlock()
+
+	name := e.segmentName(seq)
+	tmp := name + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.segs = append(e.segs, spoolSegment{seq: seq, size: size})
+	sort.Slice(e.segs, func(i, j int) bool {
+		return e.segs[i].seq < e.segs[j].seq
+	})
+	e.bytes += size
+	for e.bytes > e.maxBytes && len(e.segs) > 1 {
+		oldest := e.segs[0]
+		e.segs = e.segs[1:]
+		e.bytes -= oldest.size
+		if err := os.Remove(e.segmentName(oldest.seq)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[otel] spool evict error: %v", err)
+		}
+		atomic.AddUint64(&e.evicted, 1)
+	}
+
+	return nil
+}
+
+// failSegment counts a failed replay of the segment seq, it reports whether
+// the segment is rejected: it failed e.attempts times, and the wrapped
+// exporter exported other batches since its first failure.
+func (e *SpoolExporter) failSegment(seq uint64) bool {
+	exported := atomic.LoadUint64(&e.exported)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.segs {
+		seg := &e.segs[i]
+		if seg.seq != seq {
+			continue
+		}
+		if seg.attempts == 0 {
+			seg.exported = exported
+		}
+		seg.attempts++
+		return seg.attempts >= e.attempts && exported > seg.exported
+	}
+
+	return false
+}
+
+func (e *SpoolExporter) removeSegment(seq uint64, remove bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, seg := range e.segs {
+		if seg.seq != seq {
+			continue
+		}
+		e.segs = append(e.segs[:i], e.segs[i+1:]...)
+		e.bytes -= seg.size
+		break
+	}
+	if remove {
+		if err := os.Remove(e.segmentName(seq)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[otel] spool remove error: %v", err)
+		}
+	}
+}
+
+func (e *SpoolExporter) segmentName(seq uint64) string {
+	return filepath.Join(e.dir, fmt.Sprintf("%020d%s", seq, spoolSuffix))
+}
+
+func (e *SpoolExporter) load() error {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return fmt.Errorf("spool dir error: %s", err.Error())
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		e.segs = append(e.segs, spoolSegment{seq: seq, size: info.Size()})
+		e.bytes += info.Size()
+		if seq >= e.nextSeq {
+			e.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(e.segs, func(i, j int) bool {
+		return e.segs[i].seq < e.segs[j].seq
+	})
+
+	return nil
+}
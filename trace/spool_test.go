@@ -0,0 +1,216 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeOtlpServer is an OTLP/HTTP endpoint whose availability can be toggled.
+type fakeOtlpServer struct {
+	*httptest.Server
+	up       int32
+	requests int32
+}
+
+func newFakeOtlpServer() *fakeOtlpServer {
+	s := &fakeOtlpServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.up) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&s.requests, 1)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	return s
+}
+
+func (s *fakeOtlpServer) setUp(up bool) {
+	if up {
+		atomic.StoreInt32(&s.up, 1)
+	} else {
+		atomic.StoreInt32(&s.up, 0)
+	}
+}
+
+func TestSpoolExporter(t *testing.T) {
+	server := newFakeOtlpServer()
+	defer server.Close()
+
+	otlp, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithInsecure(),
+		otlptracehttp.WithEndpoint(strings.TrimPrefix(server.URL, "http://")),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+	)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	spool, err := NewSpoolExporter(otlp, SpoolConfig{Dir: dir, RetryInterval: time.Hour})
+	assert.Nil(t, err)
+
+	exportTestSpans(t, &noShutdownExporter{spool}, "span1", "span2")
+	stats := spool.Stats()
+	assert.Equal(t, 2, stats.QueuedBatches)
+	assert.Equal(t, uint64(2), stats.SpooledBatches)
+	assert.True(t, stats.QueuedBytes > 0)
+
+	// still down, nothing is replayed.
+	assert.NotNil(t, spool.Replay(context.Background()))
+	assert.Equal(t, 2, spool.Stats().QueuedBatches)
+
+	server.setUp(true)
+	assert.Nil(t, spool.Replay(context.Background()))
+	stats = spool.Stats()
+	assert.Equal(t, 0, stats.QueuedBatches)
+	assert.Equal(t, int64(0), stats.QueuedBytes)
+	assert.Equal(t, uint64(2), stats.ReplayedBatches)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+	assert.Nil(t, spool.Shutdown(context.Background()))
+}
+
+func TestSpoolExporterEviction(t *testing.T) {
+	dir := t.TempDir()
+	failing := &failingExporter{}
+	spool, err := NewSpoolExporter(failing, SpoolConfig{Dir: dir, MaxBytes: 1500, RetryInterval: time.Hour})
+	assert.Nil(t, err)
+
+	exportTestSpans(t, &noShutdownExporter{spool}, "span1", "span2", "span3", "span4")
+	stats := spool.Stats()
+	assert.True(t, stats.QueuedBytes <= 1500)
+	assert.True(t, stats.EvictedBatches > 0)
+	assert.Equal(t, uint64(4), stats.SpooledBatches)
+	assert.Nil(t, spool.Shutdown(context.Background()))
+
+	// the batches left on disk are loaded by the next process.
+	memory := tracetest.NewInMemoryExporter()
+	spool, err = NewSpoolExporter(memory, SpoolConfig{Dir: dir, RetryInterval: 10 * time.Millisecond})
+	assert.Nil(t, err)
+	defer spool.Shutdown(context.Background())
+	assert.Equal(t, stats.QueuedBatches, spool.Stats().QueuedBatches)
+
+	assert.Eventually(t, func() bool {
+		return spool.Stats().QueuedBatches == 0
+	}, time.Second, 10*time.Millisecond)
+	names := spanNames(memory.GetSpans())
+	assert.Equal(t, "span4", names[len(names)-1])
+}
+
+func TestSpoolExporterRejectedBatch(t *testing.T) {
+	rejecting := &rejectingExporter{memory: tracetest.NewInMemoryExporter()}
+	spool, err := NewSpoolExporter(rejecting, SpoolConfig{Dir: t.TempDir(), RetryInterval: time.Hour, MaxAttempts: 2})
+	assert.Nil(t, err)
+	defer spool.Shutdown(context.Background())
+
+	// the collector is down, the batches are kept.
+	atomic.StoreInt32(&rejecting.down, 1)
+	exportTestSpans(t, &noShutdownExporter{spool}, "poison", "span1")
+	for i := 0; i < 3; i++ {
+		assert.NotNil(t, spool.Replay(context.Background()))
+	}
+	assert.Equal(t, 2, spool.Stats().QueuedBatches)
+
+	// the collector is up, but rejects the first batch.
+	atomic.StoreInt32(&rejecting.down, 0)
+	assert.NotNil(t, spool.Replay(context.Background()))
+	exportTestSpans(t, &noShutdownExporter{spool}, "span2")
+	assert.Nil(t, spool.Replay(context.Background()))
+
+	stats := spool.Stats()
+	assert.Equal(t, 0, stats.QueuedBatches)
+	assert.Equal(t, uint64(1), stats.RejectedBatches)
+	assert.Equal(t, uint64(1), stats.ReplayedBatches)
+	assert.Equal(t, []string{"span1", "span2"}, spanNames(rejecting.memory.GetSpans()))
+}
+
+func TestSpoolExporterOrder(t *testing.T) {
+	rejecting := &rejectingExporter{memory: tracetest.NewInMemoryExporter()}
+	spool, err := NewSpoolExporter(rejecting, SpoolConfig{Dir: t.TempDir(), RetryInterval: time.Hour})
+	assert.Nil(t, err)
+	defer spool.Shutdown(context.Background())
+
+	atomic.StoreInt32(&rejecting.down, 1)
+	exportTestSpans(t, &noShutdownExporter{spool}, "span1", "span2")
+	assert.Equal(t, 2, spool.Stats().QueuedBatches)
+
+	// the stored batches are exported before the new one.
+	atomic.StoreInt32(&rejecting.down, 0)
+	exportTestSpans(t, &noShutdownExporter{spool}, "span3")
+	stats := spool.Stats()
+	assert.Equal(t, 0, stats.QueuedBatches)
+	assert.Equal(t, uint64(2), stats.ReplayedBatches)
+	var names []string
+	for _, span := range rejecting.memory.GetSpans() {
+		names = append(names, span.Name)
+	}
+	assert.Equal(t, []string{"span1", "span2", "span3"}, names)
+}
+
+// rejectingExporter rejects the spans named poison, or all of them while down.
+type rejectingExporter struct {
+	memory *tracetest.InMemoryExporter
+	down   int32
+}
+
+func (e *rejectingExporter) ExportSpans(ctx context.Context, spans []sdk.ReadOnlySpan) error {
+	if atomic.LoadInt32(&e.down) == 1 {
+		return context.DeadlineExceeded
+	}
+	for _, span := range spans {
+		if span.Name() == "poison" {
+			return errors.New("bad request")
+		}
+	}
+
+	return e.memory.ExportSpans(ctx, spans)
+}
+
+func (e *rejectingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+type failingExporter struct{}
+
+func (failingExporter) ExportSpans(context.Context, []sdk.ReadOnlySpan) error {
+	return context.DeadlineExceeded
+}
+
+func (failingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// noShutdownExporter keeps the wrapped exporter alive after the provider shuts down.
+type noShutdownExporter struct {
+	sdk.SpanExporter
+}
+
+func (noShutdownExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestTracingSpoolStats(t *testing.T) {
+	tracing, err := New(WithBatcher(KindNoop), WithSpool(SpoolConfig{Dir: t.TempDir()}))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	stats, ok := tracing.SpoolStats()
+	assert.True(t, ok)
+	assert.Equal(t, 0, stats.QueuedBatches)
+
+	tracing, err = New(WithBatcher(KindNoop))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+	_, ok = tracing.SpoolStats()
+	assert.False(t, ok)
+}
@@ -0,0 +1,401 @@
+package trace
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultDecisionWait    = 5 * time.Second
+	defaultMaxTraces       = 10000
+	defaultMaxSpansInTrace = 1000
+	// defaultFallbackRatio keeps all the traces when no fallback ratio is set.
+	defaultFallbackRatio = 1.0
+)
+
+type (
+	// TailSamplingRule decides whether a span makes its whole trace kept.
+	TailSamplingRule func(span sdk.ReadOnlySpan) bool
+
+	// A TailSamplingConfig is the tail sampling config of Tracing.
+	TailSamplingConfig struct {
+		// DecisionWait is how long spans of a trace are buffered, defaults to 5s.
+		DecisionWait time.Duration `json:"decisionWait,omitempty" yaml:"decisionWait,omitempty"`
+		// MaxTraces bounds the number of buffered traces, defaults to 10000.
+		MaxTraces int `json:"maxTraces,omitempty" yaml:"maxTraces,omitempty"`
+		// MaxSpansInTrace bounds the number of buffered spans per trace, defaults to 1000.
+		MaxSpansInTrace int `json:"maxSpansInTrace,omitempty" yaml:"maxSpansInTrace,omitempty"`
+		// LatencyThreshold keeps traces having a span longer than it, zero disables it.
+		LatencyThreshold time.Duration `json:"latencyThreshold,omitempty" yaml:"latencyThreshold,omitempty"`
+		// FallbackRatio is the ratio of the remaining traces which are kept, defaults to 1,
+		// 0 keeps only the traces decided by the error, latency and rules.
+		FallbackRatio *float64 `json:"fallbackRatio,omitempty" yaml:"fallbackRatio,omitempty"`
+		// Rules keep traces having a span matching any of them.
+		Rules []TailSamplingRule `json:"-" yaml:"-"`
+	}
+
+	// TailSamplingOption is tail sampling processor option.
+	TailSamplingOption func(*tailSamplingOptions)
+
+	tailSamplingOptions struct {
+		decisionWait     time.Duration
+		maxTraces        int
+		maxSpansInTrace  int
+		latencyThreshold time.Duration
+		rules            []TailSamplingRule
+		fallback         float64
+	}
+
+	// TailSamplingStats is a snapshot of the tail sampling processor counters.
+	TailSamplingStats struct {
+		// BufferedTraces is the number of traces waiting for a decision.
+		BufferedTraces int
+		// BufferedSpans is the number of spans waiting for a decision.
+		BufferedSpans int
+		// KeptTraces is the number of traces forwarded to the next processor.
+		KeptTraces uint64
+		// DroppedTraces is the number of traces dropped by the decision.
+		DroppedTraces uint64
+		// EvictedTraces is the number of traces decided early because the buffer was full.
+		EvictedTraces uint64
+		// DroppedSpans is the number of spans dropped because their trace exceeded the span limit.
+		DroppedSpans uint64
+	}
+
+	// TailSamplingProcessor is a sdk.SpanProcessor that buffers ended spans per
+	// trace ID for a decision window, then forwards the whole trace to the next
+	// processor if it is worth keeping.
+	//
+	// A trace is kept if any span has error status, exceeds the latency threshold
+	// or matches one of the rules; otherwise the probabilistic fallback applies.
+	// When more than the max traces are buffered, the oldest one is decided early.
+	TailSamplingProcessor struct {
+		next sdk.SpanProcessor
+		opt  *tailSamplingOptions
+
+		mu      sync.Mutex
+		traces  map[trace.TraceID]*list.Element
+		order   *list.List
+		decided map[trace.TraceID]bool
+		history *list.List
+		spans   int
+
+		kept         uint64
+		dropped      uint64
+		evicted      uint64
+		droppedSpans uint64
+
+		done     chan struct{}
+		stopOnce sync.Once
+		wg       sync.WaitGroup
+	}
+
+	pendingTrace struct {
+		id      trace.TraceID
+		arrival time.Time
+		spans   []sdk.ReadOnlySpan
+		keep    bool
+	}
+)
+
+var _ sdk.SpanProcessor = (*TailSamplingProcessor)(nil)
+
+// WithDecisionWait sets how long spans of a trace are buffered before deciding.
+func WithDecisionWait(d time.Duration) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.decisionWait = d
+	}
+}
+
+// WithMaxTraces sets the max number of traces buffered in memory.
+func WithMaxTraces(n int) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.maxTraces = n
+	}
+}
+
+// WithMaxSpansInTrace sets the max number of spans buffered for a single trace.
+func WithMaxSpansInTrace(n int) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.maxSpansInTrace = n
+	}
+}
+
+// WithLatencyThreshold keeps traces having a span longer than d.
+func WithLatencyThreshold(d time.Duration) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.latencyThreshold = d
+	}
+}
+
+// WithTailSamplingRule keeps traces having a span matching any of the rules.
+func WithTailSamplingRule(rules ...TailSamplingRule) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.rules = append(opts.rules, rules...)
+	}
+}
+
+// WithFallbackRatio sets the ratio of the remaining traces which are kept,
+// defaults to 1, 0 keeps only the traces decided by the error, latency and rules.
+func WithFallbackRatio(ratio float64) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.fallback = ratio
+	}
+}
+
+// AttributeRule returns a TailSamplingRule matching spans having the given attribute.
+func AttributeRule(kv attribute.KeyValue) TailSamplingRule {
+	return func(span sdk.ReadOnlySpan) bool {
+		for _, attr := range span.Attributes() {
+			if attr.Key == kv.Key && attr.Value == kv.Value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c TailSamplingConfig) options() []TailSamplingOption {
+	fallback := defaultFallbackRatio
+	if c.FallbackRatio != nil {
+		fallback = *c.FallbackRatio
+	}
+
+	opts := []TailSamplingOption{
+		WithLatencyThreshold(c.LatencyThreshold),
+		WithFallbackRatio(fallback),
+		WithTailSamplingRule(c.Rules...),
+	}
+	if c.DecisionWait > 0 {
+		opts = append(opts, WithDecisionWait(c.DecisionWait))
+	}
+	if c.MaxTraces > 0 {
+		opts = append(opts, WithMaxTraces(c.MaxTraces))
+	}
+	if c.MaxSpansInTrace > 0 {
+		opts = append(opts, WithMaxSpansInTrace(c.MaxSpansInTrace))
+	}
+
+	return opts
+}
+
+// NewTailSamplingProcessor create tail sampling processor instance, kept
+// traces are handed to next.
+func NewTailSamplingProcessor(next sdk.SpanProcessor, opts ...TailSamplingOption) *TailSamplingProcessor {
+	op := tailSamplingOptions{
+		decisionWait:    defaultDecisionWait,
+		maxTraces:       defaultMaxTraces,
+		maxSpansInTrace: defaultMaxSpansInTrace,
+		fallback:        defaultFallbackRatio,
+	}
+	for _, o := range opts {
+		o(&op)
+	}
+	if op.maxTraces <= 0 {
+		op.maxTraces = defaultMaxTraces
+	}
+	if op.maxSpansInTrace <= 0 {
+		op.maxSpansInTrace = defaultMaxSpansInTrace
+	}
+
+	p := &TailSamplingProcessor{
+		next:    next,
+		opt:     &op,
+		traces:  make(map[trace.TraceID]*list.Element),
+		order:   list.New(),
+		decided: make(map[trace.TraceID]bool),
+		history: list.New(),
+		done:    make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// OnStart forwards the span to the next processor.
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s sdk.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd buffers the span until its trace is decided.
+func (p *TailSamplingProcessor) OnEnd(s sdk.ReadOnlySpan) {
+	id := s.SpanContext().TraceID()
+	keep := p.match(s)
+
+	p.mu.Lock()
+	// late span of a decided trace follows the decision.
+	if kept, ok := p.decided[id]; ok {
+		p.mu.Unlock()
+		if kept {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	var pt *pendingTrace
+	if el, ok := p.traces[id]; ok {
+		pt = el.Value.(*pendingTrace)
+	} else {
+		pt = &pendingTrace{id: id, arrival: time.Now()}
+		p.traces[id] = p.order.PushBack(pt)
+	}
+
+	if len(pt.spans) >= p.opt.maxSpansInTrace {
+		atomic.AddUint64(&p.droppedSpans, 1)
+	} else {
+		pt.spans = append(pt.spans, s)
+		p.spans++
+	}
+	pt.keep = pt.keep || keep
+
+	var evicted []*pendingTrace
+	for p.order.Len() > p.opt.maxTraces {
+		evicted = append(evicted, p.remove(p.order.Front()))
+		atomic.AddUint64(&p.evicted, 1)
+	}
+	p.mu.Unlock()
+
+	for _, t := range evicted {
+		p.decide(t)
+	}
+}
+
+// Stats returns the current counters of the processor.
+func (p *TailSamplingProcessor) Stats() TailSamplingStats {
+	p.mu.Lock()
+	traces, spans := p.order.Len(), p.spans
+	p.mu.Unlock()
+
+	return TailSamplingStats{
+		BufferedTraces: traces,
+		BufferedSpans:  spans,
+		KeptTraces:     atomic.LoadUint64(&p.kept),
+		DroppedTraces:  atomic.LoadUint64(&p.dropped),
+		EvictedTraces:  atomic.LoadUint64(&p.evicted),
+		DroppedSpans:   atomic.LoadUint64(&p.droppedSpans),
+	}
+}
+
+// ForceFlush decides all buffered traces and flushes the next processor.
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.flush(time.Time{})
+	return p.next.ForceFlush(ctx)
+}
+
+// Shutdown decides all buffered traces and shuts down the next processor.
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+	p.flush(time.Time{})
+	return p.next.Shutdown(ctx)
+}
+
+func (p *TailSamplingProcessor) run() {
+	defer p.wg.Done()
+
+	interval := p.opt.decisionWait / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.flush(now.Add(-p.opt.decisionWait))
+		}
+	}
+}
+
+// flush decides the traces arrived before deadline, a zero deadline decides all.
+func (p *TailSamplingProcessor) flush(deadline time.Time) {
+	var ready []*pendingTrace
+	p.mu.Lock()
+	for el := p.order.Front(); el != nil; el = p.order.Front() {
+		pt := el.Value.(*pendingTrace)
+		if !deadline.IsZero() && pt.arrival.After(deadline) {
+			break
+		}
+		ready = append(ready, p.remove(el))
+	}
+	p.mu.Unlock()
+
+	for _, pt := range ready {
+		p.decide(pt)
+	}
+}
+
+// remove must be called with the lock held.
+func (p *TailSamplingProcessor) remove(el *list.Element) *pendingTrace {
+	pt := p.order.Remove(el).(*pendingTrace)
+	delete(p.traces, pt.id)
+	p.spans -= len(pt.spans)
+	return pt
+}
+
+func (p *TailSamplingProcessor) decide(pt *pendingTrace) {
+	keep := pt.keep || p.sampled(pt.id)
+
+	p.mu.Lock()
+	p.decided[pt.id] = keep
+	p.history.PushBack(pt.id)
+	for p.history.Len() > p.opt.maxTraces {
+		delete(p.decided, p.history.Remove(p.history.Front()).(trace.TraceID))
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		atomic.AddUint64(&p.dropped, 1)
+		return
+	}
+
+	atomic.AddUint64(&p.kept, 1)
+	for _, s := range pt.spans {
+		p.next.OnEnd(s)
+	}
+}
+
+func (p *TailSamplingProcessor) match(s sdk.ReadOnlySpan) bool {
+	if s.Status().Code == codes.Error {
+		return true
+	}
+	if p.opt.latencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.opt.latencyThreshold {
+		return true
+	}
+	for _, rule := range p.opt.rules {
+		if rule(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sampled applies the fallback ratio the same way as sdk.TraceIDRatioBased.
+func (p *TailSamplingProcessor) sampled(id trace.TraceID) bool {
+	if p.opt.fallback >= 1 {
+		return true
+	}
+	if p.opt.fallback <= 0 {
+		return false
+	}
+
+	bound := uint64(p.opt.fallback * (1 << 63))
+	x := binary.BigEndian.Uint64(id[8:16]) >> 1
+	return x < bound
+}
@@ -0,0 +1,140 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTailSamplingProvider(opts ...TailSamplingOption) (*sdk.TracerProvider, *tracetest.SpanRecorder, *TailSamplingProcessor) {
+	recorder := tracetest.NewSpanRecorder()
+	processor := NewTailSamplingProcessor(recorder, opts...)
+	return sdk.NewTracerProvider(sdk.WithSpanProcessor(processor)), recorder, processor
+}
+
+func TestTailSamplingProcessor(t *testing.T) {
+	provider, recorder, processor := newTailSamplingProvider(
+		WithDecisionWait(time.Hour),
+		WithTailSamplingRule(AttributeRule(attribute.Bool("debug", true))),
+		WithFallbackRatio(0),
+	)
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer(TraceName)
+
+	// error trace
+	ctx, root := tracer.Start(context.Background(), "error-root")
+	_, child := tracer.Start(ctx, "error-child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	root.End()
+
+	// rule trace
+	_, span := tracer.Start(context.Background(), "debug")
+	span.SetAttributes(attribute.Bool("debug", true))
+	span.End()
+
+	// dropped trace
+	_, span = tracer.Start(context.Background(), "ok")
+	span.End()
+
+	stats := processor.Stats()
+	assert.Equal(t, 3, stats.BufferedTraces)
+	assert.Equal(t, 4, stats.BufferedSpans)
+	assert.Empty(t, recorder.Ended())
+
+	assert.Nil(t, processor.ForceFlush(context.Background()))
+	names := make([]string, 0)
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	assert.ElementsMatch(t, []string{"error-child", "error-root", "debug"}, names)
+
+	stats = processor.Stats()
+	assert.Equal(t, 0, stats.BufferedTraces)
+	assert.Equal(t, uint64(2), stats.KeptTraces)
+	assert.Equal(t, uint64(1), stats.DroppedTraces)
+}
+
+func TestTailSamplingProcessorDecisionWindow(t *testing.T) {
+	provider, recorder, _ := newTailSamplingProvider(
+		WithDecisionWait(10*time.Millisecond),
+		WithFallbackRatio(1),
+	)
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer(TraceName).Start(context.Background(), "fallback")
+	span.End()
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.Ended()) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestTailSamplingProcessorEviction(t *testing.T) {
+	provider, recorder, processor := newTailSamplingProvider(
+		WithDecisionWait(time.Hour),
+		WithMaxTraces(2),
+		WithLatencyThreshold(time.Nanosecond),
+	)
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer(TraceName)
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "slow")
+		time.Sleep(time.Millisecond)
+		span.End()
+	}
+
+	stats := processor.Stats()
+	assert.Equal(t, 2, stats.BufferedTraces)
+	assert.Equal(t, uint64(1), stats.EvictedTraces)
+	assert.Equal(t, uint64(1), stats.KeptTraces)
+	assert.Len(t, recorder.Ended(), 1)
+}
+
+func TestTracingTailSampling(t *testing.T) {
+	tracing, err := New(WithBatcher(KindNoop), WithTailSampling(TailSamplingConfig{}))
+	assert.Nil(t, err)
+	defer tracing.Shutdown(context.Background())
+
+	_, ok := tracing.TailSamplingStats()
+	assert.True(t, ok)
+}
+
+func TestTailSamplingProcessorDefaultFallback(t *testing.T) {
+	provider, recorder, processor := newTailSamplingProvider(WithDecisionWait(time.Hour))
+	tracer := provider.Tracer(TraceName)
+
+	// a trace matching no rule is kept by default.
+	_, span := tracer.Start(context.Background(), "ok")
+	span.End()
+	assert.Nil(t, provider.Shutdown(context.Background()))
+	assert.Equal(t, uint64(1), processor.Stats().KeptTraces)
+	assert.Len(t, recorder.Ended(), 1)
+}
+
+func TestTailSamplingConfigFallbackRatio(t *testing.T) {
+	fallback := func(c TailSamplingConfig) float64 {
+		var op tailSamplingOptions
+		for _, opt := range c.options() {
+			opt(&op)
+		}
+		return op.fallback
+	}
+
+	// an omitted ratio keeps the traces instead of dropping them all.
+	assert.Equal(t, 1.0, fallback(TailSamplingConfig{}))
+	ratio := 0.0
+	assert.Equal(t, 0.0, fallback(TailSamplingConfig{FallbackRatio: &ratio}))
+
+	c, err := LoadConfig(strings.NewReader("batcher: noop\ntailSampling:\n  fallbackRatio: 0\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, fallback(*c.TailSampling))
+}
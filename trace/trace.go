@@ -21,6 +21,15 @@ type TracerOption func(*tracerOptions)
 
 type tracerOptions struct {
 	propagator propagation.TextMapPropagator
+	classifier ErrorClassifier
+}
+
+// defaultPropagator returns the propagator of NewTracer, with the extra ones.
+func defaultPropagator(extra ...propagation.TextMapPropagator) propagation.TextMapPropagator {
+	propagators := []propagation.TextMapPropagator{propagation.Baggage{}, propagation.TraceContext{}, b3.New()}
+	propagators = append(propagators, extra...)
+
+	return propagation.NewCompositeTextMapPropagator(propagators...)
 }
 
 // WithPropagator with tracer propagator.
@@ -30,10 +39,17 @@ func WithPropagator(propagator propagation.TextMapPropagator) TracerOption {
 	}
 }
 
+// WithErrorClassifier with the ErrorClassifier of Tracer.RecordError.
+func WithErrorClassifier(classifier ErrorClassifier) TracerOption {
+	return func(opts *tracerOptions) {
+		opts.classifier = classifier
+	}
+}
+
 // NewTracer create tracer instance
 func NewTracer(kind trace.SpanKind, opts ...TracerOption) *Tracer {
 	op := tracerOptions{
-		propagator: propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{}, b3.New()),
+		propagator: defaultPropagator(),
 	}
 
 	for _, o := range opts {
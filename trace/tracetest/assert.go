@@ -0,0 +1,207 @@
+package tracetest
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktracetest "go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Spans is a list of recorded spans.
+type Spans []sdktracetest.SpanStub
+
+// SpanAssert is a fluent assertion on a recorded span, every failure is reported to t.
+type SpanAssert struct {
+	t     testing.TB
+	span  *sdktracetest.SpanStub
+	spans Spans
+}
+
+// Find returns the first span named name.
+func (s Spans) Find(name string) (sdktracetest.SpanStub, bool) {
+	for _, span := range s {
+		if span.Name == name {
+			return span, true
+		}
+	}
+
+	return sdktracetest.SpanStub{}, false
+}
+
+// FindAll returns all spans named name.
+func (s Spans) FindAll(name string) Spans {
+	var out Spans
+	for _, span := range s {
+		if span.Name == name {
+			out = append(out, span)
+		}
+	}
+
+	return out
+}
+
+// Parent returns the parent of span.
+func (s Spans) Parent(span sdktracetest.SpanStub) (sdktracetest.SpanStub, bool) {
+	if !span.Parent.IsValid() {
+		return sdktracetest.SpanStub{}, false
+	}
+	for _, p := range s {
+		if p.SpanContext.SpanID() == span.Parent.SpanID() && p.SpanContext.TraceID() == span.Parent.TraceID() {
+			return p, true
+		}
+	}
+
+	return sdktracetest.SpanStub{}, false
+}
+
+// Assert returns the assertions on the first span named name, it fails t if there is none.
+func (s Spans) Assert(t testing.TB, name string) *SpanAssert {
+	t.Helper()
+
+	a := &SpanAssert{t: t, spans: s}
+	span, ok := s.Find(name)
+	if !ok {
+		t.Errorf("tracetest: span %q not found in %v", name, s.names())
+		return a
+	}
+	a.span = &span
+
+	return a
+}
+
+func (s Spans) names() []string {
+	out := make([]string, 0, len(s))
+	for _, span := range s {
+		out = append(out, span.Name)
+	}
+
+	return out
+}
+
+// Stub returns the asserted span, the zero SpanStub if it was not found.
+func (a *SpanAssert) Stub() sdktracetest.SpanStub {
+	if a.span == nil {
+		return sdktracetest.SpanStub{}
+	}
+
+	return *a.span
+}
+
+// HasAttributes asserts the span has all the attributes.
+func (a *SpanAssert) HasAttributes(kvs ...attribute.KeyValue) *SpanAssert {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, kv := range kvs {
+		var found bool
+		for _, attr := range a.span.Attributes {
+			if attr.Key == kv.Key {
+				found = true
+				if attr.Value != kv.Value {
+					a.t.Errorf("tracetest: span %q attribute %s is %s, want %s",
+						a.span.Name, kv.Key, attr.Value.Emit(), kv.Value.Emit())
+				}
+				break
+			}
+		}
+		if !found {
+			a.t.Errorf("tracetest: span %q has no attribute %s", a.span.Name, kv.Key)
+		}
+	}
+
+	return a
+}
+
+// HasStatus asserts the span status code.
+func (a *SpanAssert) HasStatus(code codes.Code) *SpanAssert {
+	a.t.Helper()
+	if a.span != nil && a.span.Status.Code != code {
+		a.t.Errorf("tracetest: span %q status is %s, want %s", a.span.Name, a.span.Status.Code, code)
+	}
+
+	return a
+}
+
+// HasKind asserts the span kind.
+func (a *SpanAssert) HasKind(kind trace.SpanKind) *SpanAssert {
+	a.t.Helper()
+	if a.span != nil && a.span.SpanKind != kind {
+		a.t.Errorf("tracetest: span %q kind is %s, want %s", a.span.Name, a.span.SpanKind, kind)
+	}
+
+	return a
+}
+
+// HasEvent asserts the span has an event named name with all the attributes.
+func (a *SpanAssert) HasEvent(name string, kvs ...attribute.KeyValue) *SpanAssert {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	for _, ev := range a.span.Events {
+		if ev.Name == name && containsAll(ev.Attributes, kvs) {
+			return a
+		}
+	}
+	a.t.Errorf("tracetest: span %q has no event %q with attributes %v", a.span.Name, name, kvs)
+
+	return a
+}
+
+// IsRoot asserts the span has no parent.
+func (a *SpanAssert) IsRoot() *SpanAssert {
+	a.t.Helper()
+	if a.span != nil && a.span.Parent.IsValid() {
+		a.t.Errorf("tracetest: span %q is not a root span", a.span.Name)
+	}
+
+	return a
+}
+
+// HasParentChain asserts the names of the ancestors of the span, nearest first.
+// For example HasParentChain("handler", "server") for a span started in the
+// handler span, which is a child of the server span.
+func (a *SpanAssert) HasParentChain(names ...string) *SpanAssert {
+	a.t.Helper()
+	if a.span == nil {
+		return a
+	}
+
+	current := *a.span
+	for _, name := range names {
+		parent, ok := a.spans.Parent(current)
+		if !ok {
+			a.t.Errorf("tracetest: span %q has no recorded parent, want %q", current.Name, name)
+			return a
+		}
+		if parent.Name != name {
+			a.t.Errorf("tracetest: parent of span %q is %q, want %q", current.Name, parent.Name, name)
+			return a
+		}
+		current = parent
+	}
+
+	return a
+}
+
+func containsAll(attrs, kvs []attribute.KeyValue) bool {
+	for _, kv := range kvs {
+		var found bool
+		for _, attr := range attrs {
+			if attr == kv {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,103 @@
+// Package tracetest provides a Tracing backed by an in-memory recorder and
+// fluent assertions on the recorded spans.
+package tracetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nextmicro/gokit/trace"
+	sdktracetest "go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const waitInterval = 5 * time.Millisecond
+
+// Recorder records the spans of a trace.Tracing using the KindMemory batcher.
+type Recorder struct {
+	tracing  *trace.Tracing
+	exporter *sdktracetest.InMemoryExporter
+}
+
+// New returns a Recorder, the Batcher option is always KindMemory.
+func New(opts ...trace.Option) (*Recorder, error) {
+	tracing, err := trace.New(append(opts, trace.WithBatcher(trace.KindMemory))...)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, ok := tracing.Exporter().(*sdktracetest.InMemoryExporter)
+	if !ok {
+		tracing.Shutdown(context.Background())
+		return nil, fmt.Errorf("unexpected exporter: %T", tracing.Exporter())
+	}
+
+	return &Recorder{tracing: tracing, exporter: exporter}, nil
+}
+
+// MustNew returns a Recorder and fails t on error, the Tracing is shut down when t ends.
+func MustNew(t testing.TB, opts ...trace.Option) *Recorder {
+	t.Helper()
+
+	r, err := New(opts...)
+	if err != nil {
+		t.Fatalf("tracetest: %v", err)
+	}
+	t.Cleanup(func() {
+		r.Shutdown(context.Background())
+	})
+
+	return r
+}
+
+// Tracing returns the recorded trace.Tracing.
+func (r *Recorder) Tracing() *trace.Tracing {
+	return r.tracing
+}
+
+// Flush synchronously exports the ended spans to the recorder.
+func (r *Recorder) Flush() error {
+	return r.tracing.ForceFlush(context.Background())
+}
+
+// Spans flushes and returns the recorded spans.
+func (r *Recorder) Spans() Spans {
+	_ = r.Flush()
+	return Spans(r.exporter.GetSpans())
+}
+
+// Reset removes the recorded spans.
+func (r *Recorder) Reset() {
+	_ = r.Flush()
+	r.exporter.Reset()
+}
+
+// Shutdown shuts down the recorded trace.Tracing.
+func (r *Recorder) Shutdown(ctx context.Context) error {
+	return r.tracing.Shutdown(ctx)
+}
+
+// WaitForSpans waits until at least n spans are recorded, it fails t on timeout.
+func (r *Recorder) WaitForSpans(t testing.TB, n int, timeout time.Duration) Spans {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		spans := r.Spans()
+		if len(spans) >= n {
+			return spans
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("tracetest: got %d spans, want %d after %s", len(spans), n, timeout)
+			return spans
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+// Span flushes and returns the assertions on the first span named name.
+func (r *Recorder) Span(t testing.TB, name string) *SpanAssert {
+	t.Helper()
+	return r.Spans().Assert(t, name)
+}
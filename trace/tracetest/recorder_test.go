@@ -0,0 +1,86 @@
+package tracetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nextmicro/gokit/trace"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRecorder(t *testing.T) {
+	r := MustNew(t, trace.WithName("tracetest"))
+
+	tracer := trace.NewTracer(oteltrace.SpanKindServer)
+	ctx, server := tracer.Start(context.Background(), "server")
+	ctx, handler := trace.NewTracer(oteltrace.SpanKindInternal).Start(ctx, "handler")
+	_, query := trace.NewTracer(oteltrace.SpanKindClient).Start(ctx, "query")
+	query.SetAttributes(attribute.String("db.system", "mysql"))
+	query.AddEvent("retry", oteltrace.WithAttributes(attribute.Int("attempt", 1)))
+	query.SetStatus(codes.Error, "timeout")
+	query.End()
+	handler.End()
+	server.End()
+
+	spans := r.WaitForSpans(t, 3, time.Second)
+	assert.Len(t, spans, 3)
+
+	r.Span(t, "query").
+		HasKind(oteltrace.SpanKindClient).
+		HasStatus(codes.Error).
+		HasAttributes(attribute.String("db.system", "mysql")).
+		HasEvent("retry", attribute.Int("attempt", 1)).
+		HasParentChain("handler", "server")
+	r.Span(t, "server").IsRoot().HasKind(oteltrace.SpanKindServer)
+
+	r.Reset()
+	assert.Empty(t, r.Spans())
+}
+
+func TestSpanAssertFailures(t *testing.T) {
+	r := MustNew(t)
+
+	_, span := trace.NewTracer(oteltrace.SpanKindInternal).Start(context.Background(), "span")
+	span.End()
+
+	mock := &fakeTB{}
+	r.Span(mock, "missing")
+	assert.Len(t, mock.errors, 1)
+	assert.Contains(t, mock.errors[0], `span "missing" not found`)
+
+	mock = &fakeTB{}
+	r.Span(mock, "span").HasKind(oteltrace.SpanKindServer)
+	assert.Len(t, mock.errors, 1)
+	assert.Contains(t, mock.errors[0], "kind is internal, want server")
+
+	mock = &fakeTB{}
+	r.Span(mock, "span").HasParentChain("parent")
+	assert.Len(t, mock.errors, 1)
+	assert.Contains(t, mock.errors[0], "has no recorded parent")
+
+	mock = &fakeTB{}
+	r.Span(mock, "span").IsRoot().HasStatus(codes.Unset)
+	assert.Empty(t, mock.errors)
+}
+
+// fakeTB is a testing.TB recording the failures, the methods it does not
+// override panic on the nil embedded testing.TB.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
@@ -4,22 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
-	"go.opentelemetry.io/otel/exporters/zipkin"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdk "go.opentelemetry.io/otel/sdk/trace"
-	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Tracing struct {
-	op       *Config
-	provider *sdk.TracerProvider
+	op          *Config
+	provider    *sdk.TracerProvider
+	exporter    sdk.SpanExporter
+	sampler     *DynamicSampler
+	tailSampler *TailSamplingProcessor
+	spool       *SpoolExporter
+	propagator  propagation.TextMapPropagator
+
+	watchMu     sync.Mutex
+	stopWatches []context.CancelFunc
 }
 
 func New(opts ...Option) (*Tracing, error) {
@@ -30,40 +34,72 @@ func New(opts ...Option) (*Tracing, error) {
 	for _, opt := range opts {
 		opt.apply(op)
 	}
+	if err := op.Validate(); err != nil {
+		return nil, err
+	}
 
 	o := &Tracing{op: op}
 
-	r, err := resource.New(context.Background(),
-		resource.WithOS(),
-		resource.WithHost(),
-		resource.WithFromEnv(), // pull attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables
-		resource.WithProcess(), // This option configures a set of Detectors that discover process information
-		resource.WithTelemetrySDK(),
-		resource.WithAttributes(op.Attributes...),
-	)
+	r, err := newResource(context.Background(), op)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := op.Sampler
+	o.sampler, err = NewDynamicSampler(SamplerConfig{Ratio: &ratio, Rules: op.SamplingRules})
 	if err != nil {
 		return nil, err
 	}
 
 	options := []sdk.TracerProviderOption{
-		// Set the sampling rate based on the parent span to 100%
-		sdk.WithSampler(sdk.ParentBased(sdk.TraceIDRatioBased(op.Sampler))),
+		// Set the sampling rate based on the parent span, root spans use the dynamic sampler.
+		sdk.WithSampler(sdk.ParentBased(o.sampler)),
 		// Record information about this application in an Resource.
 		sdk.WithResource(r),
 	}
 
+	for _, sp := range op.SpanProcessors {
+		options = append(options, sdk.WithSpanProcessor(sp))
+	}
+
 	var exp sdk.SpanExporter
 	exp, err = o.createExporter()
 	if err != nil {
 		return nil, err
 	}
+	o.exporter = exp
+	if op.Spool != nil {
+		o.spool, err = NewSpoolExporter(exp, *op.Spool)
+		if err != nil {
+			return nil, err
+		}
+		exp = o.spool
+	}
+	// redact before spooling, so that no sensitive value reaches the disk.
+	if op.Redact != nil {
+		exp, err = NewRedactExporter(exp, *op.Redact)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// Always be sure to batch in production.
-	options = append(options, sdk.WithBatcher(exp))
+	if op.TailSampling != nil {
+		o.tailSampler = NewTailSamplingProcessor(sdk.NewBatchSpanProcessor(exp), op.TailSampling.options()...)
+		options = append(options, sdk.WithSpanProcessor(o.tailSampler))
+	} else {
+		options = append(options, sdk.WithBatcher(exp))
+	}
 	o.provider = sdk.NewTracerProvider(options...)
 	otel.SetTracerProvider(o.provider)
 
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{}, propagation.Baggage{}))
+	// Jaeger clients propagate the uber-trace-id header.
+	var extra []propagation.TextMapPropagator
+	if op.Batcher == KindJaeger {
+		extra = append(extra, JaegerPropagator())
+	}
+	propagators := append([]propagation.TextMapPropagator{propagation.TraceContext{}, propagation.Baggage{}}, extra...)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagators...))
+	o.propagator = defaultPropagator(extra...)
 	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
 		log.Printf("[otel] error: %v", err)
 	}))
@@ -71,54 +107,103 @@ func New(opts ...Option) (*Tracing, error) {
 	return o, nil
 }
 
+// Propagator returns the propagator of the tracers of t, the one of NewTracer
+// with the propagators required by the batcher, like the Jaeger one.
+func (t *Tracing) Propagator() propagation.TextMapPropagator {
+	return t.propagator
+}
+
+// NewTracer is like NewTracer, with the Propagator of t by default.
+func (t *Tracing) NewTracer(kind trace.SpanKind, opts ...TracerOption) *Tracer {
+	return NewTracer(kind, append([]TracerOption{WithPropagator(t.propagator)}, opts...)...)
+}
+
+// NewExporter returns the sdk.SpanExporter selected by the Batcher option,
+// without installing a TracerProvider.
+func NewExporter(opts ...Option) (sdk.SpanExporter, error) {
+	op := &Config{
+		Batcher: KindStdout,
+	}
+	for _, opt := range opts {
+		opt.apply(op)
+	}
+	if err := op.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := &Tracing{op: op}
+	return t.createExporter()
+}
+
 func (t *Tracing) createExporter() (sdk.SpanExporter, error) {
-	// Just support jaeger and zipkin now, more for later
-	switch t.op.Batcher {
-	case KindZipkin:
-		return zipkin.New(t.op.Endpoint)
-	case KindOtlpGrpc:
-		// Always treat trace exporter as optional component, so we use nonblock here,
-		// otherwise this would slow down app start up even set a dial timeout here when
-		// endpoint can not reach.
-		// If the connection not dial success, the global otel ErrorHandler will catch error
-		// when reporting data like other exporters.
-		opts := []otlptracegrpc.Option{
-			otlptracegrpc.WithInsecure(),
-			otlptracegrpc.WithEndpoint(t.op.Endpoint),
-		}
-		if len(t.op.OtlpHeaders) > 0 {
-			opts = append(opts, otlptracegrpc.WithHeaders(t.op.OtlpHeaders))
-		}
-		return otlptracegrpc.New(context.Background(), opts...)
-	case KindOtlpHttp:
-		// Not support flexible configuration now.
-		opts := []otlptracehttp.Option{
-			otlptracehttp.WithInsecure(),
-			otlptracehttp.WithEndpoint(t.op.Endpoint),
-		}
-		if len(t.op.OtlpHeaders) > 0 {
-			opts = append(opts, otlptracehttp.WithHeaders(t.op.OtlpHeaders))
-		}
-		if len(t.op.OtlpHttpPath) > 0 {
-			opts = append(opts, otlptracehttp.WithURLPath(t.op.OtlpHttpPath))
-		}
-		return otlptracehttp.New(context.Background(), opts...)
-	case KindStdout:
-		return stdouttrace.New()
-	case KindFile:
-		f, err := os.OpenFile(t.op.Endpoint, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-		if err != nil {
-			return nil, fmt.Errorf("file exporter endpoint error: %s", err.Error())
-		}
-		return stdouttrace.New(stdouttrace.WithWriter(f))
-	case KindNoop:
-		return tracetest.NewNoopExporter(), nil
-	default:
+	factory, ok := lookupExporter(t.op.Batcher)
+	if !ok {
 		return nil, fmt.Errorf("unknown exporter: %s", t.op.Batcher)
 	}
+
+	return factory(t.op)
+}
+
+// UpdateSampler atomically replaces the sampling ratio and rules of root spans,
+// it is safe to call while spans are started.
+func (t *Tracing) UpdateSampler(c SamplerConfig) error {
+	return t.sampler.Update(c)
+}
+
+// SamplerConfig returns the sampling ratio and rules in use.
+func (t *Tracing) SamplerConfig() SamplerConfig {
+	return t.sampler.Config()
+}
+
+// WatchSamplerFile reloads the SamplerConfig from the YAML or JSON filename
+// whenever it changes, the file is polled every interval until Shutdown.
+// A non-positive interval defaults to 10 seconds.
+func (t *Tracing) WatchSamplerFile(filename string, interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.watchMu.Lock()
+	t.stopWatches = append(t.stopWatches, cancel)
+	t.watchMu.Unlock()
+
+	go t.sampler.Watch(ctx, filename, interval)
+}
+
+// TailSamplingStats returns the tail sampling counters, ok is false if tail sampling is disabled.
+func (t *Tracing) TailSamplingStats() (stats TailSamplingStats, ok bool) {
+	if t.tailSampler == nil {
+		return stats, false
+	}
+
+	return t.tailSampler.Stats(), true
+}
+
+// SpoolStats returns the spool counters, ok is false if the spool is disabled.
+func (t *Tracing) SpoolStats() (stats SpoolStats, ok bool) {
+	if t.spool == nil {
+		return stats, false
+	}
+
+	return t.spool.Stats(), true
+}
+
+// Exporter returns the exporter selected by the Batcher option, before it is
+// wrapped by the spool and redact options.
+func (t *Tracing) Exporter() sdk.SpanExporter {
+	return t.exporter
+}
+
+// ForceFlush exports all ended spans which have not been exported yet.
+func (t *Tracing) ForceFlush(ctx context.Context) error {
+	return t.provider.ForceFlush(ctx)
 }
 
 // Shutdown shuts down the span processors in the order they were registered.
 func (t *Tracing) Shutdown(ctx context.Context) error {
+	t.watchMu.Lock()
+	for _, cancel := range t.stopWatches {
+		cancel()
+	}
+	t.stopWatches = nil
+	t.watchMu.Unlock()
+
 	return t.provider.Shutdown(ctx)
 }
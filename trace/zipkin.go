@@ -0,0 +1,103 @@
+package trace
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// A ZipkinConfig is the config of the KindZipkin exporter.
+type ZipkinConfig struct {
+	// Timeout is the timeout of each request sent to the collector, zero means no timeout.
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Headers are added to each request, for example an auth token.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Username and Password enable the basic auth.
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// CAFile is the PEM file of the CA certificates used to verify the collector.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// CertFile and KeyFile are the PEM files of the client certificate.
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	// InsecureSkipVerify disables the verification of the collector certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	// Client overrides the http client built from the fields above.
+	Client *http.Client `json:"-" yaml:"-"`
+	// Logger logs the exporter errors, nil disables the logging.
+	Logger *log.Logger `json:"-" yaml:"-"`
+}
+
+func newZipkinExporter(endpoint string, c ZipkinConfig) (sdk.SpanExporter, error) {
+	client := c.Client
+	if client == nil {
+		var err error
+		if client, err = c.httpClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []zipkin.Option{
+		zipkin.WithClient(client),
+	}
+	if headers := c.headers(); len(headers) > 0 {
+		opts = append(opts, zipkin.WithHeaders(headers))
+	}
+	if c.Logger != nil {
+		opts = append(opts, zipkin.WithLogger(c.Logger))
+	}
+
+	return zipkin.New(endpoint, opts...)
+}
+
+func (c ZipkinConfig) headers() map[string]string {
+	headers := make(map[string]string, len(c.Headers)+1)
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	if len(c.Username) > 0 || len(c.Password) > 0 {
+		auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+		headers["Authorization"] = "Basic " + auth
+	}
+
+	return headers
+}
+
+func (c ZipkinConfig) httpClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if len(c.CAFile) > 0 {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("zipkin ca file error: %s", err.Error())
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("zipkin ca file error: no certificate found in %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(c.CertFile) > 0 || len(c.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("zipkin client certificate error: %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: transport,
+		Timeout:   c.Timeout,
+	}, nil
+}
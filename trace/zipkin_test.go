@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestZipkinExporter(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.Nil(t, os.WriteFile(caFile, ca, 0644))
+
+	exp, err := newZipkinExporter(server.URL+"/api/v2/spans", ZipkinConfig{
+		Timeout:  time.Second,
+		Headers:  map[string]string{"X-Tenant": "acme"},
+		Username: "user",
+		Password: "pass",
+		CAFile:   caFile,
+	})
+	assert.Nil(t, err)
+
+	provider := sdk.NewTracerProvider(sdk.WithSyncer(exp))
+	_, span := provider.Tracer(TraceName).Start(context.Background(), "zipkin")
+	span.End()
+	assert.Nil(t, provider.Shutdown(context.Background()))
+
+	h := <-headers
+	assert.Equal(t, "acme", h.Get("X-Tenant"))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", h.Get("Authorization"))
+}
+
+func TestZipkinExporterCAFile(t *testing.T) {
+	_, err := newZipkinExporter("http://localhost:9411/api/v2/spans", ZipkinConfig{
+		CAFile: filepath.Join(t.TempDir(), "missing.pem"),
+	})
+	assert.NotNil(t, err)
+}